@@ -0,0 +1,47 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/segmentio/ebs-backup/internal/engine"
+	"github.com/segmentio/ebs-backup/internal/handler"
+)
+
+// check checks that the newest snapshot of each selected volume is
+// within the RPO, it prints the result and exits like a Nagios plugin.
+func check(args []string) int {
+	fs := flag.NewFlagSet("check", flag.ExitOnError)
+	name := fs.String("name", "", "name tags that identify the volumes")
+	devices := fs.String("devices", "", "comma separated list of device names")
+	rpo := fs.Duration("rpo", 24*time.Hour, "maximum age of the newest snapshot of a volume")
+	limit := fs.Int("limit", 0, "expected number of snapshots per volume, zero to not check the retention")
+	fs.Parse(args)
+
+	if *name == "" || *devices == "" {
+		fmt.Println("EBS-BACKUP UNKNOWN - --name and --devices are required")
+		return handler.Unknown
+	}
+
+	e := engine.New(engine.Config{
+		EC2:     ec2.New(session.New(aws.NewConfig())),
+		Name:    *name,
+		Devices: split(*devices),
+		Limit:   *limit,
+		RPO:     *rpo,
+	})
+
+	results, err := e.Check()
+	if err != nil {
+		fmt.Printf("EBS-BACKUP UNKNOWN - %s\n", err)
+		return handler.Unknown
+	}
+
+	r := handler.NewCheckResponse(*name, *rpo, results)
+	fmt.Print(r)
+	return r.Code
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/apex/log"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/ebs"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/segmentio/ebs-backup/internal/engine"
+)
+
+// gib is the number of bytes in a GiB, the unit snapshots are billed in.
+const gib = 1 << 30
+
+// cost estimates the storage and monthly cost of the managed snapshots
+// per volume and job, and simulates them with another retention limit.
+func cost(args []string) int {
+	fs := flag.NewFlagSet("cost", flag.ExitOnError)
+	job := fs.String("job", "", "only estimate the snapshots of this backup job")
+	limit := fs.Int("limit", 0, "retention limit to simulate, zero to only estimate the current retention")
+	price := fs.Float64("price", 0.05, "snapshot storage price per GB-month")
+	fs.Parse(args)
+
+	sess := session.New(aws.NewConfig())
+
+	e := engine.New(engine.Config{
+		EC2: ec2.New(sess),
+		EBS: ebs.New(sess),
+		Job: *job,
+	})
+
+	usage, err := e.Usage(*limit)
+	if err != nil {
+		log.WithError(err).Fatal("error")
+	}
+
+	jobs := make(map[string]*engine.Usage)
+	var names []string
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "VOLUME\tJOB\tSNAPSHOTS\tGIB\tCOST\tSIMULATED GIB\tSIMULATED COST")
+
+	for _, u := range usage {
+		row(tw, u.VolumeID, u.Job, len(u.Snapshots), u.Bytes, u.SimulatedBytes, *price)
+
+		total, ok := jobs[u.Job]
+		if !ok {
+			total = &engine.Usage{Job: u.Job}
+			jobs[u.Job] = total
+			names = append(names, u.Job)
+		}
+
+		total.Snapshots = append(total.Snapshots, u.Snapshots...)
+		total.Bytes += u.Bytes
+		total.SimulatedBytes += u.SimulatedBytes
+	}
+
+	sort.Strings(names)
+	fmt.Fprintln(tw, "\t\t\t\t\t\t")
+
+	for _, name := range names {
+		t := jobs[name]
+		row(tw, "total", t.Job, len(t.Snapshots), t.Bytes, t.SimulatedBytes, *price)
+	}
+
+	tw.Flush()
+	return 0
+}
+
+// row writes an estimate row, costs are per month.
+func row(tw *tabwriter.Writer, volume, job string, snapshots int, bytes, simulated int64, price float64) {
+	size, sim := float64(bytes)/gib, float64(simulated)/gib
+	fmt.Fprintf(tw, "%s\t%s\t%d\t%.2f\t$%.2f\t%.2f\t$%.2f\n",
+		volume, job, snapshots, size, size*price, sim, sim*price)
+}
@@ -0,0 +1,303 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/apex/log/handlers/logfmt"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/recyclebin"
+	"github.com/segmentio/ebs-backup/internal/engine"
+	"github.com/segmentio/ebs-backup/internal/lock"
+	"github.com/segmentio/ebs-backup/internal/schedule"
+)
+
+// daemonConfig is the configuration file of the daemon.
+type daemonConfig struct {
+	Jobs []daemonJob `json:"Jobs"`
+}
+
+// daemonJob is a backup job run by the daemon.
+//
+// Schedule is a cron or rate expression, see `schedule.Parse`.
+// Jitter, Interval, LockTTL, the Pending and Archive
+// settings are durations such as `5m`.
+type daemonJob struct {
+	Name             string            `json:"Name"`
+	Schedule         string            `json:"Schedule"`
+	Jitter           string            `json:"Jitter"`
+	VolumeName       string            `json:"VolumeName"`
+	Devices          []string          `json:"Devices"`
+	VolumeIDs        []string          `json:"VolumeIDs"`
+	Limit            int               `json:"Limit"`
+	Interval         string            `json:"Interval"`
+	CopyTags         *bool             `json:"CopyTags"`
+	Tags             map[string]string `json:"Tags"`
+	TagInclude       []string          `json:"TagInclude"`
+	TagExclude       []string          `json:"TagExclude"`
+	TagRename        map[string]string `json:"TagRename"`
+	Description      string            `json:"Description"`
+	LockTable        string            `json:"LockTable"`
+	LockTags         bool              `json:"LockTags"`
+	LockTTL          string            `json:"LockTTL"`
+	PendingWait      string            `json:"PendingWait"`
+	PendingMaxAge    string            `json:"PendingMaxAge"`
+	ErrorSnapshots   string            `json:"ErrorSnapshots"`
+	ArchiveAfter     string            `json:"ArchiveAfter"`
+	ArchiveExpiry    string            `json:"ArchiveExpiry"`
+	FastRestoreZones []string          `json:"FastRestoreZones"`
+	Images           bool              `json:"Images"`
+	NoReboot         bool              `json:"NoReboot"`
+	DryRun           bool              `json:"DryRun"`
+}
+
+// daemon runs the backup jobs of a configuration file on their
+// schedules until it receives SIGINT or SIGTERM.
+func daemon(args []string) int {
+	fs := flag.NewFlagSet("daemon", flag.ExitOnError)
+	path := fs.String("config", "", "path to the JSON jobs configuration file")
+	addr := fs.String("addr", ":8080", "address of the /health endpoint, empty to disable")
+	fs.Parse(args)
+
+	log.SetHandler(logfmt.New(os.Stdout))
+
+	if *path == "" {
+		log.Fatal("--config is required")
+	}
+
+	sess := session.New(aws.NewConfig())
+
+	tasks, err := load(*path, sess)
+	if err != nil {
+		log.WithError(err).Fatal("config")
+	}
+
+	s := schedule.New(tasks...)
+
+	if *addr != "" {
+		mux := http.NewServeMux()
+		mux.Handle("/health", s)
+
+		go func() {
+			err := http.ListenAndServe(*addr, mux)
+			log.WithError(err).Fatal("health")
+		}()
+	}
+
+	stop := make(chan struct{})
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGINT, syscall.SIGTERM)
+
+	go func() {
+		log.WithField("signal", <-sig).Info("stopping, waiting for running jobs")
+		close(stop)
+	}()
+
+	log.WithField("jobs", len(tasks)).Info("daemon")
+	s.Run(stop)
+	return 0
+}
+
+// load reads the configuration file at `path` and returns a task per job.
+func load(path string, sess *session.Session) ([]schedule.Task, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var c daemonConfig
+	if err := json.Unmarshal(b, &c); err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err)
+	}
+
+	if len(c.Jobs) == 0 {
+		return nil, fmt.Errorf("%s: no jobs", path)
+	}
+
+	names := make(map[string]bool)
+	tasks := make([]schedule.Task, 0, len(c.Jobs))
+
+	for _, j := range c.Jobs {
+		if j.Name == "" || names[j.Name] {
+			return nil, fmt.Errorf("job name %q is empty or not unique", j.Name)
+		}
+		names[j.Name] = true
+
+		t, err := j.task(sess)
+		if err != nil {
+			return nil, fmt.Errorf("job %s: %s", j.Name, err)
+		}
+
+		tasks = append(tasks, t)
+	}
+
+	return tasks, nil
+}
+
+// task returns the scheduled task that runs the job.
+func (j daemonJob) task(sess *session.Session) (schedule.Task, error) {
+	var t schedule.Task
+
+	sched, err := schedule.Parse(j.Schedule)
+	if err != nil {
+		return t, err
+	}
+
+	jitter, err := duration(j.Jitter)
+	if err != nil {
+		return t, fmt.Errorf("Jitter: %s", err)
+	}
+
+	c, err := j.config(sess)
+	if err != nil {
+		return t, err
+	}
+
+	return schedule.Task{
+		Name:     j.Name,
+		Schedule: sched,
+		Jitter:   jitter,
+		Run: func() error {
+			e := engine.New(c)
+
+			results, err := e.Run()
+			if err != nil {
+				return err
+			}
+
+			var failed int
+			for _, res := range results {
+				if res.Err != nil {
+					failed++
+				}
+			}
+
+			if failed > 0 {
+				return fmt.Errorf("%d of %d volumes failed", failed, len(results))
+			}
+
+			return nil
+		},
+	}, nil
+}
+
+// config returns the engine config of the job.
+func (j daemonJob) config(sess *session.Session) (engine.Config, error) {
+	var c engine.Config
+
+	if j.Limit > 1000 || j.Limit <= 1 {
+		return c, fmt.Errorf("Limit must be less than 1000 and greater than 1")
+	}
+
+	if len(j.VolumeIDs) == 0 && j.VolumeName == "" {
+		return c, fmt.Errorf("VolumeName is required without VolumeIDs")
+	}
+
+	if len(j.VolumeIDs) == 0 && len(j.Devices) == 0 {
+		return c, fmt.Errorf("Devices is required without VolumeIDs")
+	}
+
+	interval, err := duration(j.Interval)
+	if err != nil {
+		return c, fmt.Errorf("Interval: %s", err)
+	}
+
+	lockTTL, err := duration(j.LockTTL)
+	if err != nil {
+		return c, fmt.Errorf("LockTTL: %s", err)
+	}
+
+	pendingWait, err := duration(j.PendingWait)
+	if err != nil {
+		return c, fmt.Errorf("PendingWait: %s", err)
+	}
+
+	if lockTTL == 0 {
+		lockTTL = lock.DefaultTTL
+	}
+
+	if (j.LockTable != "" || j.LockTags) && pendingWait >= lockTTL {
+		return c, fmt.Errorf("PendingWait must be less than LockTTL")
+	}
+
+	pendingMaxAge, err := duration(j.PendingMaxAge)
+	if err != nil {
+		return c, fmt.Errorf("PendingMaxAge: %s", err)
+	}
+
+	archiveAfter, err := duration(j.ArchiveAfter)
+	if err != nil {
+		return c, fmt.Errorf("ArchiveAfter: %s", err)
+	}
+
+	archiveExpiry, err := duration(j.ArchiveExpiry)
+	if err != nil {
+		return c, fmt.Errorf("ArchiveExpiry: %s", err)
+	}
+
+	errPolicy, err := engine.ParseErrorPolicy(j.ErrorSnapshots)
+	if err != nil {
+		return c, fmt.Errorf("ErrorSnapshots: %s", err)
+	}
+
+	copyTags := true
+	if j.CopyTags != nil {
+		copyTags = *j.CopyTags
+	}
+
+	description := engine.DefaultDescription
+	if j.Description != "" {
+		description = j.Description
+	}
+
+	return engine.Config{
+		EC2:       ec2.New(sess),
+		EC2Region: regional(sess),
+		Rbin:      recyclebin.New(sess),
+		Region:    aws.StringValue(sess.Config.Region),
+		Name:      j.VolumeName,
+		Devices:   j.Devices,
+		VolumeIDs: j.VolumeIDs,
+		Limit:     j.Limit,
+		Interval:  interval,
+		CopyTags:  copyTags,
+		Tags:      j.Tags,
+		TagRules: engine.TagRules{
+			Include: j.TagInclude,
+			Exclude: j.TagExclude,
+			Rename:  j.TagRename,
+		},
+		Job:              j.Name,
+		Description:      description,
+		Version:          version,
+		Locker:           locker(sess, j.LockTable, j.LockTags, lockTTL),
+		LockTTL:          lockTTL,
+		PendingWait:      pendingWait,
+		PendingMaxAge:    pendingMaxAge,
+		ErrorSnapshots:   errPolicy,
+		ArchiveAfter:     archiveAfter,
+		ArchiveExpiry:    archiveExpiry,
+		FastRestoreZones: j.FastRestoreZones,
+		Images:           j.Images,
+		NoReboot:         j.NoReboot,
+		DryRun:           j.DryRun,
+	}, nil
+}
+
+// duration parses `s`, an empty string is a zero duration.
+func duration(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+
+	return time.ParseDuration(s)
+}
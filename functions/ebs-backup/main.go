@@ -1,22 +1,29 @@
 package main
 
 import (
-	"errors"
+	"context"
 	"fmt"
 	"os"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/apex/log"
 	"github.com/apex/log/handlers/logfmt"
 	"github.com/aws/aws-lambda-go/lambda"
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
 	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+	"github.com/aws/aws-sdk-go/service/recyclebin"
 	"github.com/segmentio/ebs-backup/internal/engine"
 	"github.com/segmentio/ebs-backup/internal/handler"
+	"github.com/segmentio/ebs-backup/internal/lock"
 )
 
+var version = "v0.0.0"
+
 var env = []string{
 	"VOLUME_NAME",
 	"VOLUME_DEVICES",
@@ -33,45 +40,76 @@ func main() {
 	lambda.Start(HandleRequest)
 }
 
-func HandleRequest() (r handler.Response, err error) {
+func HandleRequest(ctx context.Context, ev handler.Event) (r handler.Response, err error) {
 	c, err := config()
 	if err != nil {
 		return r, err
 	}
 
+	c, err = override(c, ev)
+	if err != nil {
+		return r, err
+	}
+
+	// the wait for pending snapshots ends before the function
+	// times out, it cannot be longer than the timeout itself.
+	if deadline, ok := ctx.Deadline(); ok {
+		c.Deadline = deadline
+		if c.PendingWait >= time.Until(deadline) {
+			return r, fmt.Errorf("$PENDING_WAIT must be less than the function timeout")
+		}
+	}
+
 	e := engine.New(c)
+	start := time.Now()
 
 	results, err := e.Run()
 	if err != nil {
 		return r, err
 	}
 
-	errOccurred := false
-
 	for _, res := range results {
 		fields := log.Fields{
-			"name_tag":    e.Name,
-			"snapshot_id": res.CreatedSnapshot,
-			"volume_id":   res.VolumeID,
+			"name_tag":     e.Name,
+			"snapshot_id":  res.CreatedSnapshot,
+			"volume_id":    res.VolumeID,
+			"instance_id":  res.InstanceID,
+			"image_id":     res.CreatedImage,
+			"deleted":      res.DeletedSnapshots,
+			"skipped":      res.Skipped,
+			"pending":      res.PendingSnapshot,
+			"errored":      res.ErroredSnapshots,
+			"archived":     res.ArchivedSnapshots,
+			"held":         res.HeldSnapshots,
+			"protected":    res.ProtectedSnapshots,
+			"fast_restore": res.FastRestoreSnapshot,
+			"recycle_bin":  res.RecycleBinRule,
+			"dry_run":      e.DryRun,
 		}
-		result := handler.Result{
-			Name:       e.Name,
-			SnapshotID: res.CreatedSnapshot,
-			VolumeID:   res.VolumeID,
+		if res.DRRegion != "" {
+			fields["dr_region"] = res.DRRegion
+			fields["copied_snapshot"] = res.CopiedSnapshot
 		}
 		if res.Err == nil {
 			log.WithFields(fields).Info("snapshot")
 		} else {
-			errOccurred = true
 			fields["error"] = res.Err.Error()
+			fields["error_phase"] = engine.ErrorPhase(res.Err)
+			fields["error_code"] = engine.ErrorCode(res.Err)
+			if failed := engine.FailedDeletions(res.Err); failed != nil {
+				fields["not_deleted"] = failed
+			}
 			log.WithFields(fields).Error("snapshot")
-			result.Error = res.Err.Error()
 		}
-		r = append(r, result)
 	}
 
-	if errOccurred {
-		return r, errors.New("An error occurred")
+	r = handler.NewResponse(e.Name, e.DryRun, results, time.Since(start))
+
+	// Lambda drops the response of a failed invocation, so failed
+	// volumes are only reported in `.Summary.Failed` unless the
+	// invocation is configured to fail on them.
+	if r.Summary.Failed > 0 && os.Getenv("FAIL_ON_ERROR") == "true" {
+		return r, fmt.Errorf("%d volume(s) failed", r.Summary.Failed)
 	}
 	return r, nil
 }
@@ -102,7 +140,85 @@ func config() (c engine.Config, err error) {
 		return c, fmt.Errorf("$VOLUME_DEVICES is required")
 	}
 
-	c.EC2 = ec2.New(session.New(aws.NewConfig()))
+	if c.Interval, err = parseDuration("SNAPSHOT_INTERVAL"); err != nil {
+		return c, err
+	}
+
+	if c.PendingWait, err = parseDuration("PENDING_WAIT"); err != nil {
+		return c, err
+	}
+
+	if c.PendingMaxAge, err = parseDuration("PENDING_MAX_AGE"); err != nil {
+		return c, err
+	}
+
+	c.FastRestoreZones = list(os.Getenv("FAST_RESTORE_ZONES"))
+	c.Images = os.Getenv("IMAGES") == "true"
+	c.NoReboot = os.Getenv("NO_REBOOT") == "true"
+
+	if c.ArchiveAfter, err = parseDuration("ARCHIVE_AFTER"); err != nil {
+		return c, err
+	}
+
+	if c.ArchiveExpiry, err = parseDuration("ARCHIVE_EXPIRY"); err != nil {
+		return c, err
+	}
+
+	if c.ErrorSnapshots, err = engine.ParseErrorPolicy(os.Getenv("ERROR_SNAPSHOTS")); err != nil {
+		return c, fmt.Errorf("$ERROR_SNAPSHOTS : %s", err)
+	}
+
+	rename, err := pairs(os.Getenv("TAG_RENAME"))
+	if err != nil {
+		return c, fmt.Errorf("$TAG_RENAME : %s", err)
+	}
+
+	c.TagRules = engine.TagRules{
+		Include: list(os.Getenv("TAG_INCLUDE")),
+		Exclude: list(os.Getenv("TAG_EXCLUDE")),
+		Rename:  rename,
+	}
+
+	tags, err := pairs(os.Getenv("SNAPSHOT_TAGS"))
+	if err != nil {
+		return c, fmt.Errorf("$SNAPSHOT_TAGS : %s", err)
+	}
+
+	c.Tags = tags
+	c.Job = os.Getenv("JOB_NAME")
+	c.Description = engine.DefaultDescription
+	if v := os.Getenv("SNAPSHOT_DESCRIPTION"); v != "" {
+		c.Description = v
+	}
+	c.Version = version
+
+	sess := session.New(aws.NewConfig())
+	c.EC2 = ec2.New(sess)
+	c.Rbin = recyclebin.New(sess)
+	c.Region = aws.StringValue(sess.Config.Region)
+	c.EC2Region = func(region string) ec2iface.EC2API {
+		return ec2.New(sess, aws.NewConfig().WithRegion(region))
+	}
+
+	if table := os.Getenv("LOCK_TABLE"); table != "" {
+		c.Locker = lock.DynamoDB{
+			API:   dynamodb.New(sess),
+			Table: table,
+			Owner: lock.Owner(),
+		}
+	} else if os.Getenv("LOCK_TAGS") == "true" {
+		c.Locker = lock.Tag{
+			EC2:    c.EC2,
+			Owner:  lock.Owner(),
+			Settle: 2 * time.Second,
+		}
+	}
+	if c.Locker != nil {
+		c.LockTTL = lock.DefaultTTL
+		if c.PendingWait >= c.LockTTL {
+			return c, fmt.Errorf("$PENDING_WAIT must be less than %s when locking", c.LockTTL)
+		}
+	}
 	c.Name = os.Getenv("VOLUME_NAME")
 	c.Devices = devices
 	c.Limit = limit
@@ -110,6 +226,47 @@ func config() (c engine.Config, err error) {
 	return c, nil
 }
 
+// override applies the per-invocation overrides of `ev` to `c`.
+func override(c engine.Config, ev handler.Event) (engine.Config, error) {
+	if ev.Limit != 0 {
+		if ev.Limit < 1 {
+			return c, fmt.Errorf("event Limit must be more than 1")
+		}
+		c.Limit = ev.Limit
+	}
+
+	if ev.Name != "" {
+		c.Name = ev.Name
+	}
+
+	if len(ev.Devices) > 0 {
+		c.Devices = ev.Devices
+	}
+
+	if ev.CopyTags != nil {
+		c.CopyTags = *ev.CopyTags
+	}
+
+	if ev.Description != "" {
+		c.Description = ev.Description
+	}
+
+	if len(ev.Tags) > 0 {
+		tags := make(map[string]string, len(c.Tags)+len(ev.Tags))
+		for k, v := range c.Tags {
+			tags[k] = v
+		}
+		for k, v := range ev.Tags {
+			tags[k] = v
+		}
+		c.Tags = tags
+	}
+
+	c.VolumeIDs = ev.VolumeIDs
+	c.DryRun = ev.DryRun
+	return c, nil
+}
+
 func parseInt(key string) (int, error) {
 	v, err := strconv.Atoi(os.Getenv(key))
 	if err != nil {
@@ -119,6 +276,20 @@ func parseInt(key string) (int, error) {
 	return v, nil
 }
 
+func parseDuration(key string) (time.Duration, error) {
+	v := os.Getenv(key)
+	if v == "" {
+		return 0, nil
+	}
+
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return 0, fmt.Errorf("$%s : %s", key, err)
+	}
+
+	return d, nil
+}
+
 func parseBool(key string) (bool, error) {
 	v, err := strconv.ParseBool(os.Getenv(key))
 	if err != nil {
@@ -134,3 +305,22 @@ func split(s string) (ret []string) {
 	}
 	return ret
 }
+
+func list(s string) []string {
+	if strings.TrimSpace(s) == "" {
+		return nil
+	}
+	return split(s)
+}
+
+func pairs(s string) (map[string]string, error) {
+	ret := make(map[string]string)
+	for _, pair := range list(s) {
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("invalid pair %q", pair)
+		}
+		ret[parts[0]] = parts[1]
+	}
+	return ret, nil
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/apex/log/handlers/logfmt"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/segmentio/ebs-backup/internal/engine"
+	"github.com/segmentio/ebs-backup/internal/handler"
+)
+
+var env = []string{
+	"VOLUME_NAME",
+	"VOLUME_DEVICES",
+	"RPO",
+}
+
+func init() {
+	log.SetHandler(logfmt.New(os.Stdout))
+	log.SetLevel(log.InfoLevel)
+}
+
+func main() {
+	lambda.Start(HandleRequest)
+}
+
+// HandleRequest checks the RPO of the configured volumes, it
+// returns an error when the check is not OK so that the
+// function's error metric and alarms fire.
+func HandleRequest() (r handler.CheckResponse, err error) {
+	c, err := config()
+	if err != nil {
+		return r, err
+	}
+
+	e := engine.New(c)
+
+	results, err := e.Check()
+	if err != nil {
+		return r, err
+	}
+
+	r = handler.NewCheckResponse(c.Name, c.RPO, results)
+
+	for _, v := range r.Volumes {
+		log.WithFields(log.Fields{
+			"volume_id": v.VolumeID,
+			"status":    v.Status,
+			"snapshots": v.Snapshots,
+			"newest":    v.Newest,
+			"age_ms":    v.AgeMS,
+		}).Info("check")
+	}
+
+	s := r.Summary
+	log.WithFields(log.Fields{
+		"state":          r.State,
+		"volumes":        s.Volumes,
+		"ok":             s.OK,
+		"missing":        s.Missing,
+		"stale":          s.Stale,
+		"under_retained": s.UnderRetained,
+		"invalid":        s.Invalid,
+		"max_age_ms":     s.MaxAgeMS,
+	}).Info("summary")
+
+	if r.Code != handler.OK {
+		return r, fmt.Errorf("backups out of compliance: %s", r.State)
+	}
+	return r, nil
+}
+
+func config() (c engine.Config, err error) {
+	for _, name := range env {
+		if v := os.Getenv(name); v == "" {
+			return c, fmt.Errorf("$%s env var is empty", name)
+		}
+	}
+
+	rpo, err := time.ParseDuration(os.Getenv("RPO"))
+	if err != nil || rpo <= 0 {
+		return c, fmt.Errorf("$RPO must be a positive duration")
+	}
+
+	var limit int
+	if v := os.Getenv("SNAPSHOT_LIMIT"); v != "" {
+		if limit, err = strconv.Atoi(v); err != nil {
+			return c, fmt.Errorf("$SNAPSHOT_LIMIT : %s", err)
+		}
+	}
+
+	return engine.Config{
+		EC2:     ec2.New(session.New(aws.NewConfig())),
+		Name:    os.Getenv("VOLUME_NAME"),
+		Devices: split(os.Getenv("VOLUME_DEVICES")),
+		Limit:   limit,
+		RPO:     rpo,
+	}, nil
+}
+
+func split(s string) (ret []string) {
+	for _, s := range strings.Split(s, ",") {
+		ret = append(ret, strings.TrimSpace(s))
+	}
+	return ret
+}
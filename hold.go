@@ -0,0 +1,96 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/apex/log"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/segmentio/ebs-backup/internal/engine"
+)
+
+// hold places the snapshots given as arguments on hold, without
+// arguments it lists the snapshots on hold.
+func hold(args []string) int {
+	fs := flag.NewFlagSet("hold", flag.ExitOnError)
+	reason := fs.String("reason", "", "reason of the hold, e.g. a ticket")
+	fs.Parse(args)
+
+	sess := session.New(aws.NewConfig())
+	e := engine.New(engine.Config{EC2: ec2.New(sess)})
+
+	ids := fs.Args()
+	if len(ids) == 0 {
+		holds, err := e.Holds()
+		if err != nil {
+			log.WithError(err).Fatal("error")
+		}
+
+		tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "SNAPSHOT\tVOLUME\tREASON\tBY")
+		for _, h := range holds {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", h.SnapshotID, h.VolumeID, h.Reason, h.By)
+		}
+		tw.Flush()
+		return 0
+	}
+
+	if *reason == "" {
+		log.Fatal("--reason is required")
+	}
+
+	by := actor(sess)
+
+	if err := e.Hold(*reason, by, ids...); err != nil {
+		log.WithError(err).Fatal("hold")
+	}
+
+	log.WithFields(log.Fields{
+		"snapshots": ids,
+		"reason":    *reason,
+		"by":        by,
+	}).Info("hold")
+	return 0
+}
+
+// release releases the holds of the snapshots given as arguments.
+func release(args []string) int {
+	fs := flag.NewFlagSet("release", flag.ExitOnError)
+	fs.Parse(args)
+
+	ids := fs.Args()
+	if len(ids) == 0 {
+		log.Fatal("snapshot ids are required")
+	}
+
+	sess := session.New(aws.NewConfig())
+	e := engine.New(engine.Config{EC2: ec2.New(sess)})
+	by := actor(sess)
+
+	if err := e.Release(by, ids...); err != nil {
+		log.WithError(err).Fatal("release")
+	}
+
+	log.WithFields(log.Fields{
+		"snapshots": ids,
+		"by":        by,
+	}).Info("release")
+	return 0
+}
+
+// actor returns the ARN of the caller for the audit trail,
+// or the local user if the caller identity is unavailable.
+func actor(sess *session.Session) string {
+	resp, err := sts.New(sess).GetCallerIdentity(&sts.GetCallerIdentityInput{})
+	if err != nil {
+		log.WithError(err).Warn("caller identity")
+		return os.Getenv("USER")
+	}
+
+	return aws.StringValue(resp.Arn)
+}
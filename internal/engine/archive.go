@@ -0,0 +1,91 @@
+package engine
+
+import (
+	"time"
+)
+
+// Tag set on snapshots moved to the archive tier,
+// its value is the time the snapshot was archived.
+const tagArchived = "ebs-backup:archived"
+
+// MinArchiveDuration is the minimum time a snapshot is billed for
+// in the archive tier, archived snapshots are never deleted sooner.
+const MinArchiveDuration = 90 * 24 * time.Hour
+
+// tiering splits the standard tier snapshots `set` of a volume by
+// archive tier, it returns the snapshots that remain in the standard
+// tier, those to move to the archive tier and the archived snapshots
+// that expired.
+//
+// Completed snapshots older than `.ArchiveAfter` are archived, and
+// archived snapshots older than `.ArchiveExpiry` are deleted once they
+// spent `MinArchiveDuration` in the archive tier. Snapshots archived
+// by someone else lack the `ebs-backup:archived` tag and never expire.
+func (e *Engine) tiering(set []Snapshot) (standard, archive, expired []Snapshot) {
+	for _, s := range set {
+		age := now().Sub(s.Created)
+
+		switch {
+		case archived(s):
+			if e.ArchiveExpiry > 0 && age >= e.ArchiveExpiry && e.archivable(s) {
+				expired = append(expired, s)
+			}
+		case e.ArchiveAfter > 0 && age >= e.ArchiveAfter && s.State == SnapshotCompleted:
+			archive = append(archive, s)
+		default:
+			standard = append(standard, s)
+		}
+	}
+
+	return standard, archive, expired
+}
+
+// archivable returns true if the archived snapshot `s` spent
+// the minimum archive duration in the archive tier.
+func (e *Engine) archivable(s Snapshot) bool {
+	value, ok := s.Tags[tagArchived]
+	if !ok {
+		return false
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return false
+	}
+
+	return now().Sub(t) >= MinArchiveDuration
+}
+
+// archived returns true if `s` is in the archive tier, temporarily
+// restored from it, or tagged `ebs-backup:archived`.
+//
+// The storage tier of a snapshot stays standard while it is being
+// archived, which takes hours, and DescribeSnapshots does not report
+// the tiering status, the tag set when the move starts covers it.
+func archived(s Snapshot) bool {
+	_, ok := s.Tags[tagArchived]
+	return ok || s.Archived
+}
+
+// archive moves the snapshots `set` to the archive tier of `a` and
+// tags them with the time, it returns the ids of the archived snapshots.
+func (e *Engine) archive(a Archiver, set []Snapshot) ([]string, error) {
+	ids := make([]string, 0, len(set))
+
+	for _, s := range set {
+		if err := a.ArchiveSnapshot(s.ID); err != nil {
+			return ids, err
+		}
+
+		ids = append(ids, s.ID)
+
+		err := e.provider().TagSnapshot(s.ID, map[string]string{
+			tagArchived: now().UTC().Format(time.RFC3339),
+		})
+		if err != nil {
+			return ids, err
+		}
+	}
+
+	return ids, nil
+}
@@ -0,0 +1,123 @@
+package engine
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/stretchr/testify/assert"
+)
+
+const day = 24 * time.Hour
+
+// archivedSnapshot returns a snapshot of the given age in the
+// archive tier, archived `since` ago.
+func archivedSnapshot(id string, age, since time.Duration) *ec2.Snapshot {
+	return &ec2.Snapshot{
+		SnapshotId:  aws.String(id),
+		StartTime:   aws.Time(now().Add(-age)),
+		State:       aws.String("completed"),
+		StorageTier: aws.String("archive"),
+		Tags: []*ec2.Tag{{
+			Key:   aws.String(tagArchived),
+			Value: aws.String(now().Add(-since).UTC().Format(time.RFC3339)),
+		}},
+	}
+}
+
+func TestTiering(t *testing.T) {
+	assert := assert.New(t)
+	defer clock(time.Unix(86400*1000, 0))()
+
+	e := New(Config{
+		ArchiveAfter:  30 * day,
+		ArchiveExpiry: 365 * day,
+	})
+
+	recent := managedSnapshot("snap-001", "vol-001", day)
+	old := managedSnapshot("snap-002", "vol-001", 31*day)
+	expired := archivedSnapshot("snap-003", 400*day, 370*day)
+	young := archivedSnapshot("snap-004", 400*day, 30*day)
+	foreign := archivedSnapshot("snap-005", 400*day, 370*day)
+	foreign.Tags = nil
+
+	standard, archive, deleted := e.tiering([]Snapshot{
+		snapshot(recent), snapshot(old), snapshot(expired), snapshot(young), snapshot(foreign),
+	})
+	assert.Equal([]Snapshot{snapshot(recent)}, standard)
+	assert.Equal([]Snapshot{snapshot(old)}, archive)
+	assert.Equal([]Snapshot{snapshot(expired)}, deleted)
+}
+
+func TestTieringInProgress(t *testing.T) {
+	assert := assert.New(t)
+	defer clock(time.Unix(86400*1000, 0))()
+
+	e := New(Config{
+		ArchiveAfter:  30 * day,
+		ArchiveExpiry: 365 * day,
+	})
+
+	moving := archivedSnapshot("snap-001", 31*day, time.Hour)
+	moving.StorageTier = aws.String("standard")
+
+	assert.True(archived(snapshot(moving)))
+
+	standard, archive, deleted := e.tiering([]Snapshot{snapshot(moving)})
+	assert.Empty(standard)
+	assert.Empty(archive)
+	assert.Empty(deleted)
+}
+
+func TestBackupArchive(t *testing.T) {
+	assert := assert.New(t)
+	defer clock(time.Unix(86400*1000, 0))()
+
+	var tiered, deleted []string
+	var tags *ec2.CreateTagsInput
+
+	e := New(Config{
+		Limit:         2,
+		ArchiveAfter:  30 * day,
+		ArchiveExpiry: 365 * day,
+		EC2: mock{
+			DescribeSnapshotsFunc: func(req *ec2.DescribeSnapshotsInput) (*ec2.DescribeSnapshotsOutput, error) {
+				return &ec2.DescribeSnapshotsOutput{
+					Snapshots: []*ec2.Snapshot{
+						managedSnapshot("snap-001", "vol-xyz", day),
+						managedSnapshot("snap-002", "vol-xyz", 31*day),
+						archivedSnapshot("snap-003", 400*day, 370*day),
+						archivedSnapshot("snap-004", 100*day, 70*day),
+					},
+				}, nil
+			},
+			CreateSnapshotFunc: func(*ec2.CreateSnapshotInput) (*ec2.Snapshot, error) {
+				return &ec2.Snapshot{SnapshotId: aws.String("snap-005"), StartTime: aws.Time(now())}, nil
+			},
+			ModifySnapshotTierFunc: func(req *ec2.ModifySnapshotTierInput) (*ec2.ModifySnapshotTierOutput, error) {
+				assert.Equal("archive", *req.StorageTier)
+				tiered = append(tiered, *req.SnapshotId)
+				return &ec2.ModifySnapshotTierOutput{}, nil
+			},
+			CreateTagsFunc: func(req *ec2.CreateTagsInput) (*ec2.CreateTagsOutput, error) {
+				tags = req
+				return &ec2.CreateTagsOutput{}, nil
+			},
+			DeleteSnapshotFunc: func(req *ec2.DeleteSnapshotInput) (*ec2.DeleteSnapshotOutput, error) {
+				deleted = append(deleted, *req.SnapshotId)
+				return &ec2.DeleteSnapshotOutput{}, nil
+			},
+		},
+	})
+
+	res := e.backup(Volume{ID: "vol-xyz"})
+
+	assert.NoError(res.Err)
+	assert.Equal("snap-005", res.CreatedSnapshot)
+	assert.Equal([]string{"snap-002"}, tiered)
+	assert.Equal([]string{"snap-002"}, res.ArchivedSnapshots)
+	assert.Equal(tagArchived, *tags.Tags[0].Key)
+	assert.Equal([]string{"snap-003"}, deleted)
+	assert.Equal([]string{"snap-003"}, res.DeletedSnapshots)
+}
@@ -0,0 +1,55 @@
+package engine
+
+// Check returns the coverage of the volumes matched by the
+// engine, evaluated against `.RPO` and their retention.
+//
+// Unlike `Report` only the selected volumes are checked, a
+// selector that matches no volume returns an empty slice.
+func (e *Engine) Check() ([]Coverage, error) {
+	volumes, err := e.volumes()
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make([]Coverage, 0, len(volumes))
+
+	for _, v := range volumes {
+		set, err := e.snapshots(v.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		ret = append(ret, e.coverage(v, managed(set)))
+	}
+
+	return ret, nil
+}
+
+// managed returns the completed snapshots of `set` created by
+// a backup job, copies in a DR region and AMI snapshots
+// are excluded.
+func managed(set []Snapshot) []Snapshot {
+	var ret []Snapshot
+
+	for _, s := range set {
+		if s.State != SnapshotCompleted {
+			continue
+		}
+
+		if _, ok := s.Tags[tagJob]; !ok {
+			continue
+		}
+
+		if _, ok := s.Tags[tagSourceSnapshot]; ok {
+			continue
+		}
+
+		if fromImage(s) {
+			continue
+		}
+
+		ret = append(ret, s)
+	}
+
+	return ret
+}
@@ -0,0 +1,49 @@
+package engine
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheck(t *testing.T) {
+	assert := assert.New(t)
+	defer clock(time.Unix(86400*100, 0))()
+
+	e := New(Config{
+		RPO: 24 * time.Hour,
+		EC2: mock{
+			DescribeVolumesFunc: func(req *ec2.DescribeVolumesInput) (*ec2.DescribeVolumesOutput, error) {
+				return &ec2.DescribeVolumesOutput{
+					Volumes: []*ec2.Volume{{VolumeId: aws.String("vol-001")}},
+				}, nil
+			},
+			DescribeSnapshotsFunc: func(req *ec2.DescribeSnapshotsInput) (*ec2.DescribeSnapshotsOutput, error) {
+				pending := managedSnapshot("snap-003", "vol-001", time.Minute)
+				pending.State = aws.String("pending")
+
+				return &ec2.DescribeSnapshotsOutput{
+					Snapshots: []*ec2.Snapshot{
+						managedSnapshot("snap-001", "vol-001", 30*time.Hour),
+						{
+							SnapshotId: aws.String("snap-002"),
+							StartTime:  aws.Time(now().Add(-time.Hour)),
+							State:      aws.String("completed"),
+						},
+						pending,
+					},
+				}, nil
+			},
+		},
+	})
+
+	results, err := e.Check()
+	assert.NoError(err)
+	assert.Equal(1, len(results))
+	assert.Equal(StatusStale, results[0].Status)
+	assert.Equal(1, results[0].Snapshots)
+	assert.Equal(30*time.Hour, results[0].Age)
+}
@@ -0,0 +1,114 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// DeleteError is the error of deleting a snapshot.
+type DeleteError struct {
+	SnapshotID string
+	Err        error
+}
+
+// Error implements error.
+func (e *DeleteError) Error() string {
+	return e.SnapshotID + ": " + e.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *DeleteError) Unwrap() error {
+	return e.Err
+}
+
+// DeleteErrors are the errors of deleting a set of
+// snapshots, one per snapshot that was not deleted.
+type DeleteErrors []*DeleteError
+
+// Error implements error.
+func (e DeleteErrors) Error() string {
+	msgs := make([]string, 0, len(e))
+
+	for _, err := range e {
+		msgs = append(msgs, err.Error())
+	}
+
+	return fmt.Sprintf("%d snapshot(s) not deleted: %s", len(e), strings.Join(msgs, "; "))
+}
+
+// Unwrap returns the error of each snapshot.
+func (e DeleteErrors) Unwrap() []error {
+	ret := make([]error, 0, len(e))
+
+	for _, err := range e {
+		ret = append(ret, err)
+	}
+
+	return ret
+}
+
+// FailedDeletions returns the errors of the snapshots that failed
+// to be deleted keyed by snapshot id, if `err` is a `DeleteErrors`.
+func FailedDeletions(err error) map[string]string {
+	var errs DeleteErrors
+	if !errors.As(err, &errs) {
+		return nil
+	}
+
+	ret := make(map[string]string, len(errs))
+
+	for _, err := range errs {
+		ret[err.SnapshotID] = err.Err.Error()
+	}
+
+	return ret
+}
+
+// Delete deletes the given set of snapshots with provider `p`
+// and returns ids of all deleted snapshots.
+//
+// Snapshots on hold and snapshots in use, e.g. by an AMI or a
+// launch template, are skipped with a warning. Deletion of
+// every other snapshot is attempted, the ids of the deleted
+// ones are returned along with `DeleteErrors` for the others.
+func (e *Engine) delete(p Provider, set []Snapshot) ([]string, error) {
+	ids := make([]string, 0, len(set))
+
+	refs, err := e.used(p, set)
+	if err != nil {
+		return ids, err
+	}
+
+	var errs DeleteErrors
+
+	for _, s := range set {
+		if reason, ok := s.Tags[tagHold]; ok {
+			skip(s.ID, "on hold: "+reason)
+			continue
+		}
+
+		if reason, ok := refs[s.ID]; ok {
+			skip(s.ID, reason)
+			continue
+		}
+
+		err := p.DeleteSnapshot(s.ID)
+		if inUse(err) {
+			skip(s.ID, err.Error())
+			continue
+		}
+		if err != nil {
+			errs = append(errs, &DeleteError{SnapshotID: s.ID, Err: err})
+			continue
+		}
+
+		ids = append(ids, s.ID)
+	}
+
+	if len(errs) > 0 {
+		return ids, errs
+	}
+
+	return ids, nil
+}
@@ -0,0 +1,54 @@
+package engine
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeletePartial(t *testing.T) {
+	assert := assert.New(t)
+
+	var attempted []string
+
+	e := New(Config{
+		EC2: mock{
+			DeleteSnapshotFunc: func(req *ec2.DeleteSnapshotInput) (*ec2.DeleteSnapshotOutput, error) {
+				attempted = append(attempted, *req.SnapshotId)
+				if *req.SnapshotId != "snap-002" {
+					return nil, errors.New("boom")
+				}
+				return &ec2.DeleteSnapshotOutput{}, nil
+			},
+		},
+	})
+
+	ids, err := e.delete(e.ec2Provider(), []Snapshot{{ID: "snap-001"}, {ID: "snap-002"}, {ID: "snap-003"}})
+	assert.Equal([]string{"snap-001", "snap-002", "snap-003"}, attempted)
+	assert.Equal([]string{"snap-002"}, ids)
+	assert.EqualError(err, "2 snapshot(s) not deleted: snap-001: boom; snap-003: boom")
+	assert.Equal(map[string]string{
+		"snap-001": "boom",
+		"snap-003": "boom",
+	}, FailedDeletions(err))
+}
+
+func TestDeleteNoErrors(t *testing.T) {
+	assert := assert.New(t)
+
+	e := New(Config{
+		EC2: mock{
+			DeleteSnapshotFunc: func(*ec2.DeleteSnapshotInput) (*ec2.DeleteSnapshotOutput, error) {
+				return &ec2.DeleteSnapshotOutput{}, nil
+			},
+		},
+	})
+
+	ids, err := e.delete(e.ec2Provider(), []Snapshot{{ID: "snap-001"}})
+	assert.NoError(err)
+	assert.Equal([]string{"snap-001"}, ids)
+	assert.Nil(FailedDeletions(err))
+	assert.Nil(FailedDeletions(errors.New("boom")))
+}
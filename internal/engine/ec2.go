@@ -0,0 +1,286 @@
+package engine
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+)
+
+// EC2Provider is the Provider of EBS volumes, the default of
+// the engine.
+//
+// EC2Region returns a client for the given region, it is
+// required to copy snapshots. Region is the region of `.EC2`.
+//
+// It is a Protector of the snapshots of AMIs and launch templates,
+// an Archiver, a FastRestorer and a Replicator.
+type EC2Provider struct {
+	EC2       ec2iface.EC2API
+	EC2Region func(region string) ec2iface.EC2API
+	Region    string
+}
+
+// Volumes implements Provider.
+func (p EC2Provider) Volumes(s Selector) ([]Volume, error) {
+	volumes, err := describeVolumes(p.EC2, s)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make([]Volume, 0, len(volumes))
+
+	for _, v := range volumes {
+		ret = append(ret, volume(v))
+	}
+
+	return ret, nil
+}
+
+// Snapshots implements Provider.
+func (p EC2Provider) Snapshots(id string) ([]Snapshot, error) {
+	snapshots, err := describeSnapshots(p.EC2, id)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make([]Snapshot, 0, len(snapshots))
+
+	for _, s := range snapshots {
+		ret = append(ret, snapshot(s))
+	}
+
+	return ret, nil
+}
+
+// CreateSnapshot implements Provider.
+func (p EC2Provider) CreateSnapshot(v Volume, description string, tags map[string]string) (Snapshot, error) {
+	s, err := p.EC2.CreateSnapshot(&ec2.CreateSnapshotInput{
+		VolumeId:          aws.String(v.ID),
+		Description:       aws.String(description),
+		TagSpecifications: specs(ec2.ResourceTypeSnapshot, tagList(tags)),
+	})
+	if err != nil {
+		return Snapshot{}, err
+	}
+
+	return snapshot(s), nil
+}
+
+// TagSnapshot implements Provider.
+func (p EC2Provider) TagSnapshot(id string, tags map[string]string) error {
+	_, err := p.EC2.CreateTags(&ec2.CreateTagsInput{
+		Resources: aws.StringSlice([]string{id}),
+		Tags:      tagList(tags),
+	})
+	return err
+}
+
+// DeleteSnapshot implements Provider.
+func (p EC2Provider) DeleteSnapshot(id string) error {
+	_, err := p.EC2.DeleteSnapshot(&ec2.DeleteSnapshotInput{
+		SnapshotId: aws.String(id),
+	})
+	return err
+}
+
+// CopySnapshot implements Provider.
+func (p EC2Provider) CopySnapshot(s Snapshot, region string, tags map[string]string) (Snapshot, error) {
+	if p.EC2Region == nil {
+		return Snapshot{}, ErrNotSupported
+	}
+
+	c, err := p.EC2Region(region).CopySnapshot(&ec2.CopySnapshotInput{
+		SourceRegion:      aws.String(p.Region),
+		SourceSnapshotId:  aws.String(s.ID),
+		Description:       aws.String(fmt.Sprintf("Copy of %s from %s", s.ID, p.Region)),
+		TagSpecifications: specs(ec2.ResourceTypeSnapshot, tagList(tags)),
+	})
+	if err != nil {
+		return Snapshot{}, err
+	}
+
+	return Snapshot{
+		ID:       aws.StringValue(c.SnapshotId),
+		VolumeID: s.VolumeID,
+		State:    SnapshotPending,
+		Created:  now(),
+		Tags:     tags,
+	}, nil
+}
+
+// InUse implements Protector, see `references`.
+func (p EC2Provider) InUse(ids []string) (map[string]string, error) {
+	return references(p.EC2, ids)
+}
+
+// ArchiveSnapshot implements Archiver.
+func (p EC2Provider) ArchiveSnapshot(id string) error {
+	_, err := p.EC2.ModifySnapshotTier(&ec2.ModifySnapshotTierInput{
+		SnapshotId:  aws.String(id),
+		StorageTier: aws.String(ec2.TargetStorageTierArchive),
+	})
+	return err
+}
+
+// EnableFastRestore implements FastRestorer.
+func (p EC2Provider) EnableFastRestore(id string, zones []string) error {
+	_, err := p.EC2.EnableFastSnapshotRestores(&ec2.EnableFastSnapshotRestoresInput{
+		AvailabilityZones: aws.StringSlice(zones),
+		SourceSnapshotIds: aws.StringSlice([]string{id}),
+	})
+	return err
+}
+
+// DisableFastRestore implements FastRestorer.
+func (p EC2Provider) DisableFastRestore(id string, zones []string) error {
+	_, err := p.EC2.DisableFastSnapshotRestores(&ec2.DisableFastSnapshotRestoresInput{
+		AvailabilityZones: aws.StringSlice(zones),
+		SourceSnapshotIds: aws.StringSlice([]string{id}),
+	})
+	return err
+}
+
+// UntagSnapshot implements FastRestorer.
+func (p EC2Provider) UntagSnapshot(id string, keys ...string) error {
+	tags := make([]*ec2.Tag, 0, len(keys))
+	for _, k := range keys {
+		tags = append(tags, &ec2.Tag{Key: aws.String(k)})
+	}
+
+	_, err := p.EC2.DeleteTags(&ec2.DeleteTagsInput{
+		Resources: aws.StringSlice([]string{id}),
+		Tags:      tags,
+	})
+	return err
+}
+
+// Copies implements Replicator, copies are the snapshots
+// of `region` tagged with their source volume.
+func (p EC2Provider) Copies(id, region string) ([]Snapshot, error) {
+	if p.EC2Region == nil {
+		return nil, ErrNotSupported
+	}
+
+	resp, err := p.EC2Region(region).DescribeSnapshots(&ec2.DescribeSnapshotsInput{
+		Filters: []*ec2.Filter{filter("tag:"+tagSourceVolume, id)},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make([]Snapshot, 0, len(resp.Snapshots))
+
+	for _, s := range resp.Snapshots {
+		ret = append(ret, snapshot(s))
+	}
+
+	return ret, nil
+}
+
+// Regional implements Replicator.
+func (p EC2Provider) Regional(region string) Provider {
+	return EC2Provider{
+		EC2:       p.EC2Region(region),
+		EC2Region: p.EC2Region,
+		Region:    region,
+	}
+}
+
+// describeVolumes returns the in-use volumes matched by `s`, see `volumes`.
+func describeVolumes(api ec2iface.EC2API, s Selector) ([]*ec2.Volume, error) {
+	filters := []*ec2.Filter{
+		filter("status", "in-use"),
+	}
+
+	if len(s.VolumeIDs) > 0 {
+		filters = append(filters, filter("volume-id", s.VolumeIDs...))
+	} else {
+		filters = append(filters,
+			filter("attachment.device", s.Devices...),
+			filter("tag:Name", s.Name),
+		)
+	}
+
+	resp, err := api.DescribeVolumes(&ec2.DescribeVolumesInput{
+		Filters: filters,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Volumes, nil
+}
+
+// describeSnapshots returns all snapshots that belong to the volume `id`.
+func describeSnapshots(api ec2iface.EC2API, id string) ([]*ec2.Snapshot, error) {
+	resp, err := api.DescribeSnapshots(&ec2.DescribeSnapshotsInput{
+		Filters: []*ec2.Filter{filter("volume-id", id)},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Snapshots, nil
+}
+
+// volume returns the EBS volume `v` as a provider volume, the
+// name is its `Name` tag and the first attachment is used.
+func volume(v *ec2.Volume) Volume {
+	ret := Volume{
+		ID:   aws.StringValue(v.VolumeId),
+		Tags: labels(v.Tags),
+	}
+
+	ret.Name = ret.Tags["Name"]
+
+	for _, a := range v.Attachments {
+		ret.InstanceID = aws.StringValue(a.InstanceId)
+		ret.Device = aws.StringValue(a.Device)
+		break
+	}
+
+	return ret
+}
+
+// snapshot returns the EBS snapshot `s` as a provider snapshot.
+func snapshot(s *ec2.Snapshot) Snapshot {
+	return Snapshot{
+		ID:       aws.StringValue(s.SnapshotId),
+		VolumeID: aws.StringValue(s.VolumeId),
+		State:    aws.StringValue(s.State),
+		Progress: aws.StringValue(s.Progress),
+		Created:  aws.TimeValue(s.StartTime),
+		Archived: aws.StringValue(s.StorageTier) == ec2.StorageTierArchive || s.RestoreExpiryTime != nil,
+		Tags:     labels(s.Tags),
+	}
+}
+
+// tagList returns `m` as EC2 tags sorted by key.
+func tagList(m map[string]string) []*ec2.Tag {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	ret := make([]*ec2.Tag, 0, len(keys))
+	for _, k := range keys {
+		ret = append(ret, &ec2.Tag{Key: aws.String(k), Value: aws.String(m[k])})
+	}
+
+	return ret
+}
+
+// labels returns the EC2 `tags` as a map.
+func labels(tags []*ec2.Tag) map[string]string {
+	ret := make(map[string]string, len(tags))
+
+	for _, t := range tags {
+		ret[aws.StringValue(t.Key)] = aws.StringValue(t.Value)
+	}
+
+	return ret
+}
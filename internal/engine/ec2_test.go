@@ -0,0 +1,86 @@
+package engine
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEC2Provider(t *testing.T) {
+	assert := assert.New(t)
+	defer clock(time.Unix(86400, 0))()
+
+	var created *ec2.CreateSnapshotInput
+	var copied *ec2.CopySnapshotInput
+
+	p := EC2Provider{
+		Region: "us-west-2",
+		EC2: mock{
+			DescribeVolumesFunc: func(req *ec2.DescribeVolumesInput) (*ec2.DescribeVolumesOutput, error) {
+				assert.Equal(filter("tag:Name", "db-*"), req.Filters[2])
+				return &ec2.DescribeVolumesOutput{
+					Volumes: []*ec2.Volume{{
+						VolumeId:    aws.String("vol-001"),
+						Tags:        []*ec2.Tag{{Key: aws.String("Name"), Value: aws.String("db-1")}},
+						Attachments: []*ec2.VolumeAttachment{{InstanceId: aws.String("i-001"), Device: aws.String("/dev/xvdf")}},
+					}},
+				}, nil
+			},
+			DescribeSnapshotsFunc: func(req *ec2.DescribeSnapshotsInput) (*ec2.DescribeSnapshotsOutput, error) {
+				return &ec2.DescribeSnapshotsOutput{
+					Snapshots: []*ec2.Snapshot{managedSnapshot("snap-001", "vol-001", time.Hour)},
+				}, nil
+			},
+			CreateSnapshotFunc: func(req *ec2.CreateSnapshotInput) (*ec2.Snapshot, error) {
+				created = req
+				return &ec2.Snapshot{SnapshotId: aws.String("snap-002"), StartTime: aws.Time(now())}, nil
+			},
+		},
+		EC2Region: func(region string) ec2iface.EC2API {
+			assert.Equal("us-east-1", region)
+			return mock{
+				CopySnapshotFunc: func(req *ec2.CopySnapshotInput) (*ec2.CopySnapshotOutput, error) {
+					copied = req
+					return &ec2.CopySnapshotOutput{SnapshotId: aws.String("snap-003")}, nil
+				},
+			}
+		},
+	}
+
+	volumes, err := p.Volumes(Selector{Name: "db-*", Devices: []string{"/dev/xvdf"}})
+	assert.NoError(err)
+	assert.Equal([]Volume{{
+		ID:         "vol-001",
+		Name:       "db-1",
+		InstanceID: "i-001",
+		Device:     "/dev/xvdf",
+		Tags:       map[string]string{"Name": "db-1"},
+	}}, volumes)
+
+	snapshots, err := p.Snapshots("vol-001")
+	assert.NoError(err)
+	assert.Equal(1, len(snapshots))
+	assert.Equal("snap-001", snapshots[0].ID)
+	assert.Equal(SnapshotCompleted, snapshots[0].State)
+	assert.Equal(now().Add(-time.Hour), snapshots[0].Created)
+
+	s, err := p.CreateSnapshot(volumes[0], "backup", map[string]string{tagJob: "db"})
+	assert.NoError(err)
+	assert.Equal("snap-002", s.ID)
+	assert.Equal("backup", *created.Description)
+	assert.Equal(specs(ec2.ResourceTypeSnapshot, []*ec2.Tag{
+		{Key: aws.String(tagJob), Value: aws.String("db")},
+	}), created.TagSpecifications)
+
+	c, err := p.CopySnapshot(s, "us-east-1", nil)
+	assert.NoError(err)
+	assert.Equal("snap-003", c.ID)
+	assert.Equal("us-west-2", *copied.SourceRegion)
+
+	_, err = EC2Provider{}.CopySnapshot(s, "us-east-1", nil)
+	assert.Equal(ErrNotSupported, err)
+}
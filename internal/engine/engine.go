@@ -1,40 +1,143 @@
 package engine
 
 import (
-	"errors"
+	"fmt"
 	"sort"
-	"strings"
+	"time"
 
 	"github.com/apex/log"
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ebs/ebsiface"
 	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+	"github.com/aws/aws-sdk-go/service/recyclebin/recyclebiniface"
 	"github.com/tj/go-sync/semaphore"
 )
 
+// Tags set on snapshots copied to a DR region.
+const (
+	tagSourceVolume   = "ebs-backup:source-volume"
+	tagSourceSnapshot = "ebs-backup:source-snapshot"
+)
+
+// now returns the current time, it is replaced in tests.
+var now = time.Now
+
 // byTime sorts snapshots by time.
-type byTime []*ec2.Snapshot
+type byTime []Snapshot
 
-func (v byTime) Less(i, j int) bool { return (*v[i].StartTime).After(*v[j].StartTime) }
+func (v byTime) Less(i, j int) bool { return v[i].Created.After(v[j].Created) }
 func (v byTime) Len() int           { return len(v) }
 func (v byTime) Swap(i, j int)      { v[i], v[j] = v[j], v[i] }
 
 // Result represents a backup result.
+//
+// Skipped holds the reason the volume was skipped, if it was.
+// The pending fields describe the oldest snapshot of the volume
+// that was pending when the backup started, if any.
+// The DR fields are set when the volume is tagged
+// with `ebs-backup:dr-region`. The RecycleBin fields
+// are set when snapshots were deleted and a Recycle
+// Bin rule retains them. The image fields are set
+// instead of VolumeID when backing up instances.
 type Result struct {
-	VolumeID         string
-	CreatedSnapshot  string
-	DeletedSnapshots []string
-	CopiedTags       bool
-	Err              error
+	VolumeID            string
+	InstanceID          string
+	CreatedImage        string
+	DeregisteredImages  []string
+	CreatedSnapshot     string
+	DeletedSnapshots    []string
+	CopiedTags          bool
+	Skipped             string
+	PendingSnapshot     string
+	PendingProgress     string
+	PendingAge          time.Duration
+	ErroredSnapshots    []string
+	HeldSnapshots       []string
+	ProtectedSnapshots  []string
+	FastRestoreSnapshot string
+	FastRestoreDisabled []string
+	ArchivedSnapshots   []string
+	RecycleBinRule      string
+	RecycleBinRetention time.Duration
+	DRRegion            string
+	CopiedSnapshot      string
+	DeletedCopies       []string
+	Duration            time.Duration
+	Err                 error
 }
 
 // Config is the engine Config.
+//
+// EC2Region returns a client for the given region, it is
+// required to copy snapshots of volumes that are tagged with
+// `ebs-backup:dr-region`. Region is the region of `.EC2`.
+//
+// Job names the backup job, it defaults to `.Name`. Description
+// and the values of `.Tags` are templates executed with `Meta`.
+//
+// Locker, if set, is used so that only one run at a time
+// backs up a given volume, LockTTL is the duration of its locks.
+//
+// PendingWait and PendingMaxAge configure how a volume with
+// a pending snapshot is handled, see `pending`. The wait ends
+// before the locks expire and before Deadline, if set, e.g. the
+// deadline of a Lambda invocation. ErrorSnapshots is the policy
+// for snapshots in the error state.
+//
+// OrphanKeep and OrphanMaxAge are the retention of the
+// snapshots of deleted volumes, see `Reap`. RPO is the maximum
+// age of the newest snapshot of a volume, see `Report`. EBS is
+// the client of the EBS direct APIs, used by `Usage`. Rbin, if
+// set, is used to report whether deleted snapshots are retained
+// by a Recycle Bin rule.
+//
+// ArchiveAfter and ArchiveExpiry configure the archive
+// tier, see `tiering`. FastRestoreZones are the zones Fast
+// Snapshot Restore is enabled in for the newest snapshot of
+// each volume, see `fastRestore`.
+//
+// Images backs up the instances the volumes are attached to
+// with AMIs instead of the volumes, see `image`. NoReboot
+// creates the AMIs without rebooting the instances.
+//
+// Provider, if set, is the block storage backend the volumes
+// are backed up with, it defaults to the `EC2Provider` of `.EC2`.
+// The other operations of the engine, e.g. `Reap`, `Report` and
+// AMIs, are specific to EC2 and always use `.EC2`.
 type Config struct {
-	EC2      ec2iface.EC2API
-	Devices  []string
-	Name     string
-	Limit    int
-	CopyTags bool
+	EC2              ec2iface.EC2API
+	EBS              ebsiface.EBSAPI
+	Rbin             recyclebiniface.RecycleBinAPI
+	Provider         Provider
+	EC2Region        func(region string) ec2iface.EC2API
+	Region           string
+	Devices          []string
+	Name             string
+	VolumeIDs        []string
+	Limit            int
+	Interval         time.Duration
+	CopyTags         bool
+	Tags             map[string]string
+	TagRules         TagRules
+	Job              string
+	Description      string
+	Version          string
+	Locker           Locker
+	LockTTL          time.Duration
+	Deadline         time.Time
+	PendingWait      time.Duration
+	PendingMaxAge    time.Duration
+	ErrorSnapshots   ErrorPolicy
+	OrphanKeep       int
+	OrphanMaxAge     time.Duration
+	RPO              time.Duration
+	ArchiveAfter     time.Duration
+	ArchiveExpiry    time.Duration
+	FastRestoreZones []string
+	Images           bool
+	NoReboot         bool
+	DryRun           bool
 }
 
 // Engine represents a backup engine.
@@ -44,6 +147,10 @@ type Engine struct {
 
 // New returns a new Engine.
 func New(c Config) Engine {
+	if c.Job == "" {
+		c.Job = c.Name
+	}
+
 	return Engine{c}
 }
 
@@ -58,8 +165,20 @@ func (e *Engine) Run() ([]Result, error) {
 		return nil, err
 	}
 
+	found := make([]string, 0, len(volumes))
+	for _, v := range volumes {
+		found = append(found, v.ID)
+	}
+	missing := e.unmatched(found)
+
+	if e.Images {
+		return append(e.images(volumes), missing...), nil
+	}
+
 	log.WithField("volumes", len(volumes)).Info("backup")
 
+	rule := e.recycleRule()
+
 	sema := make(semaphore.Semaphore, 10)
 	resc := make(chan Result)
 
@@ -68,8 +187,14 @@ func (e *Engine) Run() ([]Result, error) {
 			volume := v
 
 			sema.Run(func() {
-				res := e.backup(volume)
-				res.VolumeID = *volume.VolumeId
+				start := time.Now()
+				res := e.locked(volume)
+				res.VolumeID = volume.ID
+				res.Duration = time.Since(start)
+				if len(res.DeletedSnapshots) > 0 && !e.DryRun {
+					res.RecycleBinRule = rule.ID
+					res.RecycleBinRetention = rule.Retention
+				}
 				resc <- res
 			})
 		}
@@ -78,14 +203,18 @@ func (e *Engine) Run() ([]Result, error) {
 		close(resc)
 	}()
 
-	results := make([]Result, 0, len(volumes))
+	results := make([]Result, 0, len(volumes)+len(missing))
+	results = append(results, missing...)
 
 	for res := range resc {
 		ctx := log.WithField("volume_id", res.VolumeID)
 
-		if res.Err != nil {
+		switch {
+		case res.Err != nil:
 			ctx.WithError(res.Err).Error("backup")
-		} else {
+		case res.Skipped != "":
+			ctx.WithField("reason", res.Skipped).Info("skipped")
+		default:
 			ctx.Info("backup")
 		}
 
@@ -95,127 +224,400 @@ func (e *Engine) Run() ([]Result, error) {
 	return results, nil
 }
 
+// unmatched returns a failed result for each of the configured
+// `.VolumeIDs` that is not in `found`, so that an ad-hoc backup of
+// a volume that is gone or detached is not reported as a success.
+func (e *Engine) unmatched(found []string) []Result {
+	var ret []Result
+
+	for _, id := range e.VolumeIDs {
+		matched := false
+		for _, f := range found {
+			matched = matched || f == id
+		}
+
+		if !matched {
+			err := fail(PhaseDescribe, ErrVolumeGone, fmt.Errorf("%s does not exist or is not in use", id))
+			log.WithField("volume_id", id).WithError(err).Error("backup")
+			ret = append(ret, Result{VolumeID: id, Err: err})
+		}
+	}
+
+	return ret
+}
+
+// recycleRule returns the Recycle Bin rule that retains deleted
+// snapshots, errors are logged since the rule is only reported.
+func (e *Engine) recycleRule() RecycleRule {
+	if e.Rbin == nil {
+		return RecycleRule{}
+	}
+
+	rule, err := e.RecycleBin()
+	if err != nil {
+		log.WithError(err).Warn("recycle bin")
+		return rule
+	}
+
+	if rule.ID == "" {
+		log.Warn("no recycle bin rule retains deleted snapshots, deletions are permanent")
+	} else {
+		log.WithFields(log.Fields{
+			"rule":      rule.ID,
+			"retention": rule.Retention,
+		}).Info("recycle bin")
+	}
+
+	return rule
+}
+
 // Volume returns all volumes that need backup.
 //
-// The method returns all volumes that satisfy
-// all the given rules:
+// The method returns the volumes of the provider matched by the
+// configured `.Name`, `.Devices` and `.VolumeIDs`, for EBS volumes
+// all volumes that satisfy all the given rules:
 //
-//    - Have a tag "Name" that matches the configured `.Name`
-//    - Have an `attachment.status` of `"attached"`
-//    - Attached at the configured `.Device`
+//   - Have a tag "Name" that matches the configured `.Name`
+//   - Have an `attachment.status` of `"attached"`
+//   - Attached at the configured `.Device`
 //
-func (e *Engine) volumes() ([]*ec2.Volume, error) {
-	resp, err := e.EC2.DescribeVolumes(&ec2.DescribeVolumesInput{
-		Filters: []*ec2.Filter{
-			filter("status", "in-use"),
-			filter("attachment.device", e.Devices...),
-			filter("tag:Name", e.Name),
-		},
+// When `.VolumeIDs` are configured they replace the name
+// and device rules, the volumes must still be attached.
+func (e *Engine) volumes() ([]Volume, error) {
+	return e.provider().Volumes(Selector{
+		Name:      e.Name,
+		Devices:   e.Devices,
+		VolumeIDs: e.VolumeIDs,
 	})
-	if err != nil {
-		return nil, err
-	}
-
-	return resp.Volumes, nil
 }
 
-// Backup will create a snapshot for the given `v`.
+// Backup will create a snapshot for the given `v` with the provider.
+//
+// Backup first resolves the volume policy, the configuration
+// overridden by the volume's `ebs-backup:*` tags. Volumes tagged
+// with `ebs-backup:skip=true` are skipped.
+//
+// Backup will then check if the newest snapshot is younger
+// than the policy interval, if it is the volume is skipped.
 //
-// Backup will first check if there is a snapshot
+// Backup then checks if there is a snapshot
 // in-progress if there is, it will abort and return
-// a result with `.Err`.
+// a result with `.Err`, unless the pending policy
+// says otherwise, see `pending`.
 //
-// After the snapshot is created the method copies the
-// volume tags and adds them to the snapshot, if `.CopyTags` is true.
+// The snapshot is created with the rendered `.Description` and
+// the volume tags, if the policy copies tags, along with the
+// configured `.Tags`, see `tags`.
 //
 // The method then checks if there's a need to delete
-// the oldest snapshot for the volume and does so if `len(snapshots) > .Limit`.
-func (e *Engine) backup(v *ec2.Volume) Result {
+// the oldest snapshot for the volume and does so if `len(snapshots) > limit`.
+// Snapshots in the error state are not counted, they are
+// reported and handled by the `.ErrorSnapshots` policy.
+// Neither are archived snapshots and those being archived,
+// nor snapshots on hold which are never deleted, nor
+// snapshots in use, e.g. by an AMI or a launch template,
+// which are reported as protected.
+//
+// The archive tier and fast restores are handled when the
+// provider supports them, see `tiering` and `fastRestore`.
+// Finally, if the policy has a DR region the newest completed
+// snapshot is copied to it, see `replicate`.
+//
+// When `.DryRun` is true nothing is created or deleted, the result
+// holds the snapshots that would have been deleted.
+func (e *Engine) backup(v Volume) Result {
 	var res Result
 
-	snapshots, err := e.snapshots(*v.VolumeId)
+	prov := e.provider()
+
+	p, err := e.policy(v)
 	if err != nil {
-		res.Err = err
+		res.Err = fail(PhasePolicy, nil, err)
 		return res
 	}
 
-	for _, s := range snapshots {
-		if strings.ToLower(*s.State) == "pending" {
-			res.Err = errors.New("volume has a snapshot in pending state")
+	if p.Skip {
+		res.Skipped = "volume is tagged " + tagSkip
+		return res
+	}
+
+	all, err := e.snapshots(v.ID)
+	if err != nil {
+		res.Err = fail(PhaseDescribe, nil, err)
+		return res
+	}
+
+	snapshots, _ := split(all)
+
+	if s := newest(snapshots); p.Interval > 0 && s != nil {
+		if age := now().Sub(s.Created); age < p.Interval {
+			res.Skipped = fmt.Sprintf("snapshot %s is %s old, interval is %s",
+				s.ID, age.Truncate(time.Second), p.Interval)
 			return res
 		}
 	}
 
-	s, err := e.EC2.CreateSnapshot(&ec2.CreateSnapshotInput{
-		VolumeId: v.VolumeId,
-	})
+	all, err = e.pending(v, all, &res)
+	if err != nil {
+		res.Err = fail(PhasePending, nil, err)
+		return res
+	}
+
+	all, hold := held(all)
+	res.HeldSnapshots = ids(hold)
+
+	all, protected, err := e.protect(prov, all)
 	if err != nil {
-		res.Err = err
+		res.Err = fail(PhaseDescribe, nil, err)
 		return res
 	}
-	res.CreatedSnapshot = *s.SnapshotId
+	res.ProtectedSnapshots = ids(protected)
+
+	snapshots, errored := split(all)
+	res.ErroredSnapshots = ids(errored)
+
+	var archive, expired []Snapshot
+
+	archiver, ok := prov.(Archiver)
+	if ok {
+		snapshots, archive, expired = e.tiering(snapshots)
+	}
+
+	_, fast := prov.(FastRestorer)
+	if !fast && len(p.FastZones) > 0 {
+		log.WithField("volume_id", v.ID).Warn("fast restores are not supported by the provider, ignoring zones")
+		p.FastZones = nil
+	}
+
+	if e.DryRun {
+		if len(snapshots)+1 > p.Limit {
+			set := byTime(snapshots)
+			sort.Sort(set)
+			res.DeletedSnapshots = ids(set[p.Limit-1:])
+		}
+		if e.ErrorSnapshots == ErrorDelete {
+			res.DeletedSnapshots = append(res.DeletedSnapshots, res.ErroredSnapshots...)
+		}
+		res.ArchivedSnapshots = ids(archive)
+		res.DeletedSnapshots = append(res.DeletedSnapshots, ids(expired)...)
+		return res
+	}
+
+	m := e.meta(v)
+
+	description, err := render(e.Description, m)
+	if err != nil {
+		res.Err = fail(PhaseCreate, nil, fmt.Errorf("description: %s", err))
+		return res
+	}
+
+	tags, err := e.tags(v, p, m)
+	if err != nil {
+		res.Err = fail(PhaseTags, ErrTagCopy, err)
+		return res
+	}
+
+	s, err := prov.CreateSnapshot(v, description, tags)
+	if err != nil {
+		res.Err = fail(PhaseCreate, nil, err)
+		return res
+	}
+	if s.Created.IsZero() {
+		s.Created = now()
+	}
+	res.CreatedSnapshot = s.ID
+	res.CopiedTags = p.CopyTags
 	snapshots = append(snapshots, s)
 
-	if e.CopyTags {
-		_, err := e.EC2.CreateTags(&ec2.CreateTagsInput{
-			Resources: []*string{s.SnapshotId},
-			Tags:      v.Tags[:],
-		})
+	if len(snapshots) > p.Limit {
+		set := byTime(snapshots)
+		sort.Sort(set)
+
+		ids, err := e.delete(prov, set[p.Limit:])
+		res.DeletedSnapshots = ids
 		if err != nil {
-			res.Err = err
+			res.Err = fail(PhasePrune, ErrPrune, err)
 			return res
 		}
+	}
 
-		res.CopiedTags = true
+	if len(errored) > 0 {
+		ids, err := e.cleanup(errored)
+		res.DeletedSnapshots = append(res.DeletedSnapshots, ids...)
+		if err != nil {
+			res.Err = fail(PhaseCleanup, nil, err)
+			return res
+		}
 	}
 
-	if len(snapshots) > e.Limit {
-		set := byTime(snapshots)
-		sort.Sort(set)
+	if len(archive) > 0 {
+		res.ArchivedSnapshots, err = e.archive(archiver, archive)
+		if err != nil {
+			res.Err = fail(PhaseArchive, nil, err)
+			return res
+		}
+	}
 
-		ids, err := e.delete(set[e.Limit:])
+	if len(expired) > 0 {
+		ids, err := e.delete(prov, expired)
+		res.DeletedSnapshots = append(res.DeletedSnapshots, ids...)
 		if err != nil {
-			res.Err = err
+			res.Err = fail(PhasePrune, ErrPrune, err)
 			return res
 		}
+	}
 
-		res.DeletedSnapshots = ids
+	others := append([]Snapshot{}, all...)
+	others = append(others, hold...)
+	others = append(others, protected...)
+	others = without(others, res.DeletedSnapshots)
+
+	if fast && (len(p.FastZones) > 0 || restored(others)) {
+		remaining := without(snapshots, res.DeletedSnapshots)
+
+		res.FastRestoreSnapshot, res.FastRestoreDisabled, err = e.fastRestore(p, remaining, others)
+		if err != nil {
+			res.Err = fail(PhaseFastRestore, nil, err)
+			return res
+		}
+	}
+
+	if p.DRRegion != "" {
+		res.DRRegion = p.DRRegion
+		res.CopiedSnapshot, res.DeletedCopies, err = e.replicate(v, p, snapshots)
+		res.Err = fail(PhaseReplicate, nil, err)
 	}
 
 	return res
 }
 
-// Snapshots returns all snapshots that belong to the volume `id`.
-func (e *Engine) snapshots(id string) ([]*ec2.Snapshot, error) {
-	resp, err := e.EC2.DescribeSnapshots(&ec2.DescribeSnapshotsInput{
-		Filters: []*ec2.Filter{filter("volume-id", id)},
-	})
+// Replicate copies the newest completed snapshot in `set` to
+// the DR region of `p` with the provider, unless it was copied
+// already.
+//
+// Copies are tagged like the snapshots of the volume and with
+// their source volume and snapshot. When the provider is a
+// Replicator the copies of the volume are listed to find out
+// whether the snapshot was copied, and the oldest copies are
+// deleted if `len(copies) > limit`. Otherwise the snapshot is
+// tagged with `ebs-backup:copied` and the id of its copy, and
+// the copies need a retention of their own.
+//
+// A snapshot can only be copied once it is completed, so the
+// snapshot created by a run is copied by the next run.
+func (e *Engine) replicate(v Volume, p policy, set []Snapshot) (string, []string, error) {
+	prov := e.provider()
+
+	var completed []Snapshot
+	for _, s := range set {
+		if s.State == SnapshotCompleted {
+			completed = append(completed, s)
+		}
+	}
+
+	src := newest(completed)
+	if src == nil {
+		return "", nil, nil
+	}
+
+	var copies []Snapshot
+
+	r, rotate := prov.(Replicator)
+	if rotate {
+		var err error
+		copies, err = r.Copies(v.ID, p.DRRegion)
+		if err != nil {
+			return "", nil, err
+		}
+
+		for _, c := range copies {
+			if c.Tags[tagSourceSnapshot] == src.ID {
+				return "", nil, nil
+			}
+		}
+	} else if _, ok := src.Tags[tagCopied]; ok {
+		return "", nil, nil
+	}
+
+	tags, err := e.tags(v, p, e.meta(v))
+	if err != nil {
+		return "", nil, err
+	}
+
+	tags[tagSourceVolume] = v.ID
+	tags[tagSourceSnapshot] = src.ID
+
+	c, err := prov.CopySnapshot(*src, p.DRRegion, tags)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if !rotate {
+		return c.ID, nil, prov.TagSnapshot(src.ID, map[string]string{tagCopied: c.ID})
+	}
+
+	if c.Created.IsZero() {
+		c.Created = now()
+	}
+	copies = append(copies, c)
+
+	if len(copies) <= p.Limit {
+		return c.ID, nil, nil
+	}
+
+	sorted := byTime(copies)
+	sort.Sort(sorted)
+
+	deleted, err := e.delete(r.Regional(p.DRRegion), sorted[p.Limit:])
+	return c.ID, deleted, err
+}
+
+// Snapshots returns all snapshots that belong to the volume `id`,
+// except the snapshots of AMIs, see `fromImage`.
+func (e *Engine) snapshots(id string) ([]Snapshot, error) {
+	list, err := e.provider().Snapshots(id)
 	if err != nil {
 		return nil, err
 	}
 
-	return resp.Snapshots, nil
+	var ret []Snapshot
+
+	for _, s := range list {
+		if !fromImage(s) {
+			ret = append(ret, s)
+		}
+	}
+
+	return ret, nil
 }
 
-// Delete deletes the given set of snapshots
-// and returns ids of all deleted snapshots.
-// If one of the snapshots fails to be deleted
-// the error is returned immediately.
-func (e *Engine) delete(set []*ec2.Snapshot) ([]string, error) {
-	ids := make([]string, 0, len(set))
+// newest returns the most recent snapshot of `set` or nil.
+func newest(set []Snapshot) *Snapshot {
+	var ret *Snapshot
 
-	for _, s := range set {
-		_, err := e.EC2.DeleteSnapshot(&ec2.DeleteSnapshotInput{
-			SnapshotId: s.SnapshotId,
-		})
-		if err != nil {
-			return nil, err
+	for i := range set {
+		s := &set[i]
+		if s.Created.IsZero() {
+			continue
 		}
 
-		ids = append(ids, *s.SnapshotId)
+		if ret == nil || s.Created.After(ret.Created) {
+			ret = s
+		}
+	}
+
+	return ret
+}
+
+// ids returns the ids of the given set of snapshots.
+func ids(set []Snapshot) []string {
+	ret := make([]string, 0, len(set))
+
+	for _, s := range set {
+		ret = append(ret, s.ID)
 	}
 
-	return ids, nil
+	return ret
 }
 
 // filter returns an ec2.Filter with `key`, `value`.
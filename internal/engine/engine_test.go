@@ -62,6 +62,64 @@ func TestVolumesMultipleDevices(t *testing.T) {
 	assert.Equal([]string{"/dev/xvdf", "/dev/xvdi"}, devices)
 }
 
+func TestVolumesByID(t *testing.T) {
+	assert := assert.New(t)
+
+	var filters []*ec2.Filter
+
+	e := New(Config{
+		Name:      "db-*",
+		Devices:   []string{"/dev/xvdf"},
+		VolumeIDs: []string{"vol-abc"},
+		EC2: mock{
+			DescribeVolumesFunc: func(req *ec2.DescribeVolumesInput) (*ec2.DescribeVolumesOutput, error) {
+				filters = req.Filters
+				return new(ec2.DescribeVolumesOutput), nil
+			},
+		},
+	})
+
+	_, err := e.volumes()
+	assert.NoError(err)
+	assert.Equal(2, len(filters))
+	assert.Equal("status", *filters[0].Name)
+	assert.Equal("volume-id", *filters[1].Name)
+	assert.Equal("vol-abc", *filters[1].Values[0])
+}
+
+func TestRunUnmatchedVolumeIDs(t *testing.T) {
+	assert := assert.New(t)
+
+	e := New(Config{
+		Name:      "db-*",
+		Limit:     2,
+		VolumeIDs: []string{"vol-abc", "vol-gone"},
+		DryRun:    true,
+		EC2: mock{
+			DescribeVolumesFunc: func(req *ec2.DescribeVolumesInput) (*ec2.DescribeVolumesOutput, error) {
+				return &ec2.DescribeVolumesOutput{
+					Volumes: []*ec2.Volume{{VolumeId: aws.String("vol-abc")}},
+				}, nil
+			},
+			DescribeSnapshotsFunc: func(req *ec2.DescribeSnapshotsInput) (*ec2.DescribeSnapshotsOutput, error) {
+				return new(ec2.DescribeSnapshotsOutput), nil
+			},
+		},
+	})
+
+	results, err := e.Run()
+	assert.NoError(err)
+	assert.Equal(2, len(results))
+
+	assert.Equal("vol-gone", results[0].VolumeID)
+	assert.True(errors.Is(results[0].Err, ErrVolumeGone))
+	assert.Equal(PhaseDescribe, ErrorPhase(results[0].Err))
+	assert.EqualError(results[0].Err, "volume not found: vol-gone does not exist or is not in use")
+
+	assert.Equal("vol-abc", results[1].VolumeID)
+	assert.NoError(results[1].Err)
+}
+
 func TestVolumesErr(t *testing.T) {
 	assert := assert.New(t)
 
@@ -109,9 +167,7 @@ func TestSnapshotsErr(t *testing.T) {
 		},
 	})
 
-	res := e.backup(&ec2.Volume{
-		VolumeId: aws.String("vol-xyz"),
-	})
+	res := e.backup(Volume{ID: "vol-xyz"})
 
 	assert.Error(res.Err)
 }
@@ -131,13 +187,315 @@ func TestSnapshotPending(t *testing.T) {
 		},
 	})
 
-	res := e.backup(&ec2.Volume{
-		VolumeId: aws.String("vol-xyz"),
+	res := e.backup(Volume{ID: "vol-xyz"})
+
+	assert.Error(res.Err)
+}
+
+func TestSnapshotInterval(t *testing.T) {
+	assert := assert.New(t)
+	defer func() { now = time.Now }()
+	now = func() time.Time { return time.Unix(0, 0).Add(time.Hour * 3) }
+
+	e := New(Config{
+		Limit:    10,
+		Interval: time.Hour * 2,
+		EC2: mock{
+			DescribeSnapshotsFunc: func(req *ec2.DescribeSnapshotsInput) (*ec2.DescribeSnapshotsOutput, error) {
+				return &ec2.DescribeSnapshotsOutput{
+					Snapshots: []*ec2.Snapshot{
+						{
+							SnapshotId: aws.String("snap-001"),
+							StartTime:  aws.Time(time.Unix(0, 0)),
+							State:      aws.String("completed"),
+						},
+						{
+							SnapshotId: aws.String("snap-002"),
+							StartTime:  aws.Time(time.Unix(0, 0).Add(time.Hour * 2)),
+							State:      aws.String("completed"),
+						},
+					},
+				}, nil
+			},
+		},
+	})
+
+	res := e.backup(Volume{ID: "vol-xyz"})
+
+	assert.NoError(res.Err)
+	assert.Equal("", res.CreatedSnapshot)
+	assert.Equal("snapshot snap-002 is 1h0m0s old, interval is 2h0m0s", res.Skipped)
+}
+
+func TestSnapshotIntervalTag(t *testing.T) {
+	assert := assert.New(t)
+	defer func() { now = time.Now }()
+	now = func() time.Time { return time.Unix(0, 0).Add(time.Hour * 3) }
+
+	e := New(Config{
+		Limit:    10,
+		Interval: time.Hour * 6,
+		EC2: mock{
+			DescribeSnapshotsFunc: func(req *ec2.DescribeSnapshotsInput) (*ec2.DescribeSnapshotsOutput, error) {
+				return &ec2.DescribeSnapshotsOutput{
+					Snapshots: []*ec2.Snapshot{
+						{
+							SnapshotId: aws.String("snap-001"),
+							StartTime:  aws.Time(time.Unix(0, 0)),
+							State:      aws.String("completed"),
+						},
+					},
+				}, nil
+			},
+			CreateSnapshotFunc: func(i *ec2.CreateSnapshotInput) (*ec2.Snapshot, error) {
+				return &ec2.Snapshot{SnapshotId: aws.String("snap-002")}, nil
+			},
+		},
+	})
+
+	res := e.backup(Volume{
+		ID:   "vol-xyz",
+		Tags: map[string]string{"ebs-backup:interval": "2h"},
+	})
+
+	assert.NoError(res.Err)
+	assert.Equal("", res.Skipped)
+	assert.Equal("snap-002", res.CreatedSnapshot)
+
+	res = e.backup(Volume{
+		ID:   "vol-xyz",
+		Tags: map[string]string{"ebs-backup:interval": "often"},
 	})
 
 	assert.Error(res.Err)
 }
 
+func TestSkipTag(t *testing.T) {
+	assert := assert.New(t)
+
+	e := New(Config{Limit: 10, EC2: mock{}})
+
+	res := e.backup(Volume{
+		ID:   "vol-xyz",
+		Tags: map[string]string{"ebs-backup:skip": "true"},
+	})
+
+	assert.NoError(res.Err)
+	assert.Equal("volume is tagged ebs-backup:skip", res.Skipped)
+}
+
+func TestRetainTag(t *testing.T) {
+	assert := assert.New(t)
+	start := time.Unix(0, 0)
+
+	var deleted []string
+
+	e := New(Config{
+		Limit: 10,
+		EC2: mock{
+			DescribeSnapshotsFunc: func(req *ec2.DescribeSnapshotsInput) (*ec2.DescribeSnapshotsOutput, error) {
+				return &ec2.DescribeSnapshotsOutput{
+					Snapshots: []*ec2.Snapshot{
+						{
+							SnapshotId: aws.String("snap-001"),
+							StartTime:  aws.Time(start.Add(time.Hour * 1)),
+							State:      aws.String("completed"),
+						},
+						{
+							SnapshotId: aws.String("snap-002"),
+							StartTime:  aws.Time(start.Add(time.Hour * 2)),
+							State:      aws.String("completed"),
+						},
+					},
+				}, nil
+			},
+			CreateSnapshotFunc: func(*ec2.CreateSnapshotInput) (*ec2.Snapshot, error) {
+				return &ec2.Snapshot{
+					SnapshotId: aws.String("snap-003"),
+					StartTime:  aws.Time(start.Add(time.Hour * 3)),
+				}, nil
+			},
+			DeleteSnapshotFunc: func(req *ec2.DeleteSnapshotInput) (*ec2.DeleteSnapshotOutput, error) {
+				deleted = append(deleted, *req.SnapshotId)
+				return nil, nil
+			},
+		},
+	})
+
+	res := e.backup(Volume{
+		ID:   "vol-xyz",
+		Tags: map[string]string{"ebs-backup:retain": "2"},
+	})
+
+	assert.NoError(res.Err)
+	assert.Equal([]string{"snap-001"}, deleted)
+}
+
+func TestReplicate(t *testing.T) {
+	assert := assert.New(t)
+	start := time.Unix(0, 0)
+
+	var copied *ec2.CopySnapshotInput
+	var deleted []string
+	var region string
+
+	dr := mock{
+		DescribeSnapshotsFunc: func(req *ec2.DescribeSnapshotsInput) (*ec2.DescribeSnapshotsOutput, error) {
+			return &ec2.DescribeSnapshotsOutput{
+				Snapshots: []*ec2.Snapshot{
+					{
+						SnapshotId: aws.String("snap-dr-001"),
+						StartTime:  aws.Time(start.Add(time.Hour * 1)),
+						Tags: []*ec2.Tag{
+							{Key: aws.String("ebs-backup:source-snapshot"), Value: aws.String("snap-000")},
+						},
+					},
+				},
+			}, nil
+		},
+		CopySnapshotFunc: func(req *ec2.CopySnapshotInput) (*ec2.CopySnapshotOutput, error) {
+			copied = req
+			return &ec2.CopySnapshotOutput{SnapshotId: aws.String("snap-dr-002")}, nil
+		},
+		DeleteSnapshotFunc: func(req *ec2.DeleteSnapshotInput) (*ec2.DeleteSnapshotOutput, error) {
+			deleted = append(deleted, *req.SnapshotId)
+			return nil, nil
+		},
+	}
+
+	e := New(Config{
+		Limit:  1,
+		Region: "us-east-1",
+		EC2Region: func(r string) ec2iface.EC2API {
+			region = r
+			return dr
+		},
+	})
+
+	set := []Snapshot{
+		{
+			ID:      "snap-001",
+			Created: start.Add(time.Hour * 2),
+			State:   "completed",
+		},
+		{
+			ID:      "snap-002",
+			Created: start.Add(time.Hour * 3),
+			State:   "pending",
+		},
+	}
+
+	id, deletedCopies, err := e.replicate(Volume{ID: "vol-xyz"}, policy{Limit: 1, DRRegion: "us-west-2"}, set)
+
+	assert.NoError(err)
+	assert.Equal("us-west-2", region)
+	assert.Equal("snap-dr-002", id)
+	assert.Equal("snap-001", *copied.SourceSnapshotId)
+	assert.Equal("us-east-1", *copied.SourceRegion)
+	assert.Equal([]string{"snap-dr-001"}, deleted)
+	assert.Equal([]string{"snap-dr-001"}, deletedCopies)
+}
+
+func TestReplicateCopied(t *testing.T) {
+	assert := assert.New(t)
+
+	e := New(Config{
+		Region: "us-east-1",
+		EC2Region: func(string) ec2iface.EC2API {
+			return mock{
+				DescribeSnapshotsFunc: func(req *ec2.DescribeSnapshotsInput) (*ec2.DescribeSnapshotsOutput, error) {
+					return &ec2.DescribeSnapshotsOutput{
+						Snapshots: []*ec2.Snapshot{
+							{
+								SnapshotId: aws.String("snap-dr-001"),
+								Tags: []*ec2.Tag{
+									{Key: aws.String("ebs-backup:source-snapshot"), Value: aws.String("snap-001")},
+								},
+							},
+						},
+					}, nil
+				},
+			}
+		},
+	})
+
+	id, _, err := e.replicate(Volume{ID: "vol-xyz"}, policy{Limit: 1, DRRegion: "us-west-2"}, []Snapshot{
+		{
+			ID:      "snap-001",
+			Created: time.Now(),
+			State:   "completed",
+		},
+	})
+
+	assert.NoError(err)
+	assert.Equal("", id)
+}
+
+func TestLocked(t *testing.T) {
+	assert := assert.New(t)
+
+	var unlocked bool
+
+	e := New(Config{
+		Limit: 10,
+		Locker: locker(func(id string) (func() error, error) {
+			assert.Equal("vol-xyz", id)
+			return func() error {
+				unlocked = true
+				return nil
+			}, nil
+		}),
+		EC2: mock{
+			DescribeSnapshotsFunc: func(req *ec2.DescribeSnapshotsInput) (*ec2.DescribeSnapshotsOutput, error) {
+				assert.False(unlocked)
+				return new(ec2.DescribeSnapshotsOutput), nil
+			},
+			CreateSnapshotFunc: func(i *ec2.CreateSnapshotInput) (*ec2.Snapshot, error) {
+				return &ec2.Snapshot{SnapshotId: aws.String("snap-xyz")}, nil
+			},
+		},
+	})
+
+	res := e.locked(Volume{ID: "vol-xyz"})
+
+	assert.NoError(res.Err)
+	assert.Equal("snap-xyz", res.CreatedSnapshot)
+	assert.True(unlocked)
+}
+
+func TestLockedByAnotherRun(t *testing.T) {
+	assert := assert.New(t)
+
+	e := New(Config{
+		Limit: 10,
+		EC2:   mock{},
+		Locker: locker(func(string) (func() error, error) {
+			return nil, ErrLocked
+		}),
+	})
+
+	res := e.locked(Volume{ID: "vol-xyz"})
+
+	assert.NoError(res.Err)
+	assert.Equal("volume is locked by another run", res.Skipped)
+}
+
+func TestLockErr(t *testing.T) {
+	assert := assert.New(t)
+
+	e := New(Config{
+		Limit: 10,
+		EC2:   mock{},
+		Locker: locker(func(string) (func() error, error) {
+			return nil, errors.New("boom")
+		}),
+	})
+
+	res := e.locked(Volume{ID: "vol-xyz"})
+
+	assert.Error(res.Err)
+}
+
 func TestCreateSnapshot(t *testing.T) {
 	assert := assert.New(t)
 
@@ -157,15 +515,111 @@ func TestCreateSnapshot(t *testing.T) {
 		},
 	})
 
-	res := e.backup(&ec2.Volume{
-		VolumeId: aws.String("vol-xyz"),
-	})
+	res := e.backup(Volume{ID: "vol-xyz"})
 
 	assert.NoError(res.Err)
 	assert.Equal("snap-xyz", res.CreatedSnapshot)
 	assert.Equal("vol-xyz", *req.VolumeId)
 }
 
+func TestCreateSnapshotTags(t *testing.T) {
+	assert := assert.New(t)
+
+	var specs []*ec2.TagSpecification
+
+	e := New(Config{
+		Limit:    10,
+		CopyTags: true,
+		Tags:     map[string]string{"b": "2", "a": "1"},
+		EC2: mock{
+			DescribeSnapshotsFunc: func(req *ec2.DescribeSnapshotsInput) (*ec2.DescribeSnapshotsOutput, error) {
+				return new(ec2.DescribeSnapshotsOutput), nil
+			},
+			CreateSnapshotFunc: func(i *ec2.CreateSnapshotInput) (*ec2.Snapshot, error) {
+				specs = i.TagSpecifications
+				return &ec2.Snapshot{SnapshotId: aws.String("snap-xyz")}, nil
+			},
+		},
+	})
+
+	res := e.backup(Volume{
+		ID: "vol-xyz",
+		Tags: map[string]string{
+			"Name":                          "db-1",
+			"aws:cloudformation:stack-name": "db",
+		},
+	})
+
+	assert.NoError(res.Err)
+	assert.True(res.CopiedTags)
+	assert.Equal(1, len(specs))
+	assert.Equal("snapshot", *specs[0].ResourceType)
+
+	tags := specs[0].Tags
+	assert.Equal(4, len(tags))
+	assert.Equal("Name", *tags[0].Key)
+	assert.Equal("a", *tags[1].Key)
+	assert.Equal("b", *tags[2].Key)
+	assert.Equal("ebs-backup:job", *tags[3].Key)
+}
+
+func TestCreateSnapshotDescription(t *testing.T) {
+	assert := assert.New(t)
+	defer func() { now = time.Now }()
+	now = func() time.Time { return time.Date(2018, 5, 1, 0, 0, 0, 0, time.UTC) }
+
+	var req *ec2.CreateSnapshotInput
+
+	e := New(Config{
+		Limit:       10,
+		Name:        "db-*",
+		Version:     "v1.2.3",
+		Description: `{{.Job}} {{.VolumeID}} {{.Name}} {{.InstanceID}}:{{.Device}} {{.Time.Format "2006-01-02"}} {{.Version}}`,
+		EC2: mock{
+			DescribeSnapshotsFunc: func(req *ec2.DescribeSnapshotsInput) (*ec2.DescribeSnapshotsOutput, error) {
+				return new(ec2.DescribeSnapshotsOutput), nil
+			},
+			CreateSnapshotFunc: func(i *ec2.CreateSnapshotInput) (*ec2.Snapshot, error) {
+				req = i
+				return &ec2.Snapshot{SnapshotId: aws.String("snap-xyz")}, nil
+			},
+		},
+	})
+
+	res := e.backup(Volume{
+		ID:         "vol-xyz",
+		Name:       "db-1",
+		InstanceID: "i-abc",
+		Device:     "/dev/xvdf",
+	})
+
+	assert.NoError(res.Err)
+	assert.False(res.CopiedTags)
+	assert.Equal("db-* vol-xyz db-1 i-abc:/dev/xvdf 2018-05-01 v1.2.3", *req.Description)
+	assert.Equal([]*ec2.Tag{
+		{Key: aws.String("ebs-backup:job"), Value: aws.String("db-*")},
+	}, req.TagSpecifications[0].Tags)
+}
+
+func TestCreateSnapshotDescriptionErr(t *testing.T) {
+	assert := assert.New(t)
+
+	e := New(Config{
+		Limit:       10,
+		Description: "{{.Volume}}",
+		EC2: mock{
+			DescribeSnapshotsFunc: func(req *ec2.DescribeSnapshotsInput) (*ec2.DescribeSnapshotsOutput, error) {
+				return new(ec2.DescribeSnapshotsOutput), nil
+			},
+		},
+	})
+
+	res := e.backup(Volume{ID: "vol-xyz"})
+
+	assert.Error(res.Err)
+	assert.Equal("", res.CreatedSnapshot)
+}
+
 func TestCreateSnapshotErr(t *testing.T) {
 	assert := assert.New(t)
 
@@ -180,9 +634,7 @@ func TestCreateSnapshotErr(t *testing.T) {
 		},
 	})
 
-	res := e.backup(&ec2.Volume{
-		VolumeId: aws.String("vol-xyz"),
-	})
+	res := e.backup(Volume{ID: "vol-xyz"})
 
 	assert.Error(res.Err)
 }
@@ -234,9 +686,7 @@ func TestDeleteSnapshots(t *testing.T) {
 		},
 	})
 
-	res := e.backup(&ec2.Volume{
-		VolumeId: aws.String("vol-xyz"),
-	})
+	res := e.backup(Volume{ID: "vol-xyz"})
 
 	assert.NoError(res.Err)
 	assert.Equal(1, len(deleted))
@@ -245,6 +695,40 @@ func TestDeleteSnapshots(t *testing.T) {
 	assert.Equal("snap-001", res.DeletedSnapshots[0])
 }
 
+func TestDryRun(t *testing.T) {
+	assert := assert.New(t)
+	start := time.Unix(0, 0)
+
+	e := New(Config{
+		Limit:  2,
+		DryRun: true,
+		EC2: mock{
+			DescribeSnapshotsFunc: func(req *ec2.DescribeSnapshotsInput) (*ec2.DescribeSnapshotsOutput, error) {
+				return &ec2.DescribeSnapshotsOutput{
+					Snapshots: []*ec2.Snapshot{
+						{
+							SnapshotId: aws.String("snap-001"),
+							StartTime:  aws.Time(start.Add(time.Hour * 1)),
+							State:      aws.String("completed"),
+						},
+						{
+							SnapshotId: aws.String("snap-002"),
+							StartTime:  aws.Time(start.Add(time.Hour * 2)),
+							State:      aws.String("completed"),
+						},
+					},
+				}, nil
+			},
+		},
+	})
+
+	res := e.backup(Volume{ID: "vol-xyz"})
+
+	assert.NoError(res.Err)
+	assert.Equal("", res.CreatedSnapshot)
+	assert.Equal([]string{"snap-001"}, res.DeletedSnapshots)
+}
+
 func TestDeleteErr(t *testing.T) {
 	assert := assert.New(t)
 
@@ -278,12 +762,18 @@ func TestDeleteErr(t *testing.T) {
 		},
 	})
 
-	res := e.backup(&ec2.Volume{
-		VolumeId: aws.String("vol-xyz"),
-	})
+	res := e.backup(Volume{ID: "vol-xyz"})
 
 	assert.Error(res.Err)
-	assert.Equal(res.Err.Error(), "boom")
+	assert.True(errors.Is(res.Err, ErrPrune))
+	assert.Equal(PhasePrune, ErrorPhase(res.Err))
+	assert.Len(FailedDeletions(res.Err), 1)
+}
+
+type locker func(string) (func() error, error)
+
+func (l locker) Lock(id string) (func() error, error) {
+	return l(id)
 }
 
 type mock struct {
@@ -293,6 +783,36 @@ type mock struct {
 	CreateSnapshotFunc    func(*ec2.CreateSnapshotInput) (*ec2.Snapshot, error)
 	DeleteSnapshotFunc    func(*ec2.DeleteSnapshotInput) (*ec2.DeleteSnapshotOutput, error)
 	CreateTagsFunc        func(*ec2.CreateTagsInput) (*ec2.CreateTagsOutput, error)
+
+	CopySnapshotFunc                   func(*ec2.CopySnapshotInput) (*ec2.CopySnapshotOutput, error)
+	EnableFastSnapshotRestoresFunc     func(*ec2.EnableFastSnapshotRestoresInput) (*ec2.EnableFastSnapshotRestoresOutput, error)
+	DisableFastSnapshotRestoresFunc    func(*ec2.DisableFastSnapshotRestoresInput) (*ec2.DisableFastSnapshotRestoresOutput, error)
+	DescribeImagesFunc                 func(*ec2.DescribeImagesInput) (*ec2.DescribeImagesOutput, error)
+	DescribeLaunchTemplateVersionsFunc func(*ec2.DescribeLaunchTemplateVersionsInput) (*ec2.DescribeLaunchTemplateVersionsOutput, error)
+	CreateImageFunc                    func(*ec2.CreateImageInput) (*ec2.CreateImageOutput, error)
+	DeregisterImageFunc                func(*ec2.DeregisterImageInput) (*ec2.DeregisterImageOutput, error)
+	DeleteTagsFunc                     func(*ec2.DeleteTagsInput) (*ec2.DeleteTagsOutput, error)
+	ModifySnapshotTierFunc             func(*ec2.ModifySnapshotTierInput) (*ec2.ModifySnapshotTierOutput, error)
+	ListSnapshotsInRecycleBinFunc      func(*ec2.ListSnapshotsInRecycleBinInput) (*ec2.ListSnapshotsInRecycleBinOutput, error)
+	RestoreSnapshotFromRecycleBinFunc  func(*ec2.RestoreSnapshotFromRecycleBinInput) (*ec2.RestoreSnapshotFromRecycleBinOutput, error)
+}
+
+func (m mock) DescribeVolumesPages(i *ec2.DescribeVolumesInput, fn func(*ec2.DescribeVolumesOutput, bool) bool) error {
+	resp, err := m.DescribeVolumesFunc(i)
+	if err != nil {
+		return err
+	}
+	fn(resp, true)
+	return nil
+}
+
+func (m mock) DescribeSnapshotsPages(i *ec2.DescribeSnapshotsInput, fn func(*ec2.DescribeSnapshotsOutput, bool) bool) error {
+	resp, err := m.DescribeSnapshotsFunc(i)
+	if err != nil {
+		return err
+	}
+	fn(resp, true)
+	return nil
 }
 
 func (m mock) DescribeVolumes(i *ec2.DescribeVolumesInput) (*ec2.DescribeVolumesOutput, error) {
@@ -311,6 +831,66 @@ func (m mock) DeleteSnapshot(i *ec2.DeleteSnapshotInput) (*ec2.DeleteSnapshotOut
 	return m.DeleteSnapshotFunc(i)
 }
 
+func (m mock) CopySnapshot(i *ec2.CopySnapshotInput) (*ec2.CopySnapshotOutput, error) {
+	return m.CopySnapshotFunc(i)
+}
+
 func (m mock) CreateTags(i *ec2.CreateTagsInput) (*ec2.CreateTagsOutput, error) {
 	return m.CreateTagsFunc(i)
 }
+
+func (m mock) ListSnapshotsInRecycleBinPages(i *ec2.ListSnapshotsInRecycleBinInput, fn func(*ec2.ListSnapshotsInRecycleBinOutput, bool) bool) error {
+	resp, err := m.ListSnapshotsInRecycleBinFunc(i)
+	if err != nil {
+		return err
+	}
+	fn(resp, true)
+	return nil
+}
+
+func (m mock) RestoreSnapshotFromRecycleBin(i *ec2.RestoreSnapshotFromRecycleBinInput) (*ec2.RestoreSnapshotFromRecycleBinOutput, error) {
+	return m.RestoreSnapshotFromRecycleBinFunc(i)
+}
+
+func (m mock) EnableFastSnapshotRestores(i *ec2.EnableFastSnapshotRestoresInput) (*ec2.EnableFastSnapshotRestoresOutput, error) {
+	return m.EnableFastSnapshotRestoresFunc(i)
+}
+
+func (m mock) DisableFastSnapshotRestores(i *ec2.DisableFastSnapshotRestoresInput) (*ec2.DisableFastSnapshotRestoresOutput, error) {
+	return m.DisableFastSnapshotRestoresFunc(i)
+}
+
+// DescribeImages returns no image unless DescribeImagesFunc is set,
+// since every backup looks for AMIs backed by the snapshots.
+func (m mock) DescribeImages(i *ec2.DescribeImagesInput) (*ec2.DescribeImagesOutput, error) {
+	if m.DescribeImagesFunc == nil {
+		return &ec2.DescribeImagesOutput{}, nil
+	}
+	return m.DescribeImagesFunc(i)
+}
+
+// DescribeLaunchTemplateVersions returns no version unless
+// DescribeLaunchTemplateVersionsFunc is set, since every deletion
+// looks for launch templates referencing the snapshots.
+func (m mock) DescribeLaunchTemplateVersions(i *ec2.DescribeLaunchTemplateVersionsInput) (*ec2.DescribeLaunchTemplateVersionsOutput, error) {
+	if m.DescribeLaunchTemplateVersionsFunc == nil {
+		return &ec2.DescribeLaunchTemplateVersionsOutput{}, nil
+	}
+	return m.DescribeLaunchTemplateVersionsFunc(i)
+}
+
+func (m mock) CreateImage(i *ec2.CreateImageInput) (*ec2.CreateImageOutput, error) {
+	return m.CreateImageFunc(i)
+}
+
+func (m mock) DeregisterImage(i *ec2.DeregisterImageInput) (*ec2.DeregisterImageOutput, error) {
+	return m.DeregisterImageFunc(i)
+}
+
+func (m mock) DeleteTags(i *ec2.DeleteTagsInput) (*ec2.DeleteTagsOutput, error) {
+	return m.DeleteTagsFunc(i)
+}
+
+func (m mock) ModifySnapshotTier(i *ec2.ModifySnapshotTierInput) (*ec2.ModifySnapshotTierOutput, error) {
+	return m.ModifySnapshotTierFunc(i)
+}
@@ -0,0 +1,81 @@
+package engine
+
+import (
+	"fmt"
+	"time"
+)
+
+// Tag set on snapshots in the error state by the `ErrorTag`
+// policy, its value is the time the error was detected.
+const tagError = "ebs-backup:error"
+
+// ErrorPolicy is what the engine does with snapshots in the error state.
+//
+// Snapshots in the error state are never counted towards
+// the retention limit and always reported, regardless
+// of the policy.
+type ErrorPolicy string
+
+// Error policies.
+const (
+	ErrorKeep   ErrorPolicy = ""
+	ErrorDelete ErrorPolicy = "delete"
+	ErrorTag    ErrorPolicy = "tag"
+)
+
+// ParseErrorPolicy parses an error policy, the
+// empty string and "keep" are the keep policy.
+func ParseErrorPolicy(s string) (ErrorPolicy, error) {
+	switch p := ErrorPolicy(s); p {
+	case ErrorKeep, ErrorDelete, ErrorTag:
+		return p, nil
+	case "keep":
+		return ErrorKeep, nil
+	default:
+		return "", fmt.Errorf("invalid error snapshot policy %q", s)
+	}
+}
+
+// split splits `set` into the snapshots that are usable and
+// those in the error state.
+func split(set []Snapshot) (usable, errored []Snapshot) {
+	for _, s := range set {
+		if s.State == SnapshotError {
+			errored = append(errored, s)
+			continue
+		}
+
+		usable = append(usable, s)
+	}
+
+	return usable, errored
+}
+
+// cleanup applies the configured `.ErrorSnapshots` policy to
+// the given set of snapshots in the error state, it returns
+// the ids of the deleted snapshots.
+//
+// The `ErrorTag` policy tags the snapshots that are not
+// tagged yet with `ebs-backup:error`.
+func (e *Engine) cleanup(set []Snapshot) ([]string, error) {
+	switch e.ErrorSnapshots {
+	case ErrorDelete:
+		return e.delete(e.provider(), set)
+	case ErrorTag:
+		value := now().UTC().Format(time.RFC3339)
+
+		for _, s := range set {
+			if _, ok := s.Tags[tagError]; ok {
+				continue
+			}
+
+			if err := e.provider().TagSnapshot(s.ID, map[string]string{tagError: value}); err != nil {
+				return nil, err
+			}
+		}
+
+		return nil, nil
+	default:
+		return nil, nil
+	}
+}
@@ -0,0 +1,162 @@
+package engine
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/stretchr/testify/assert"
+)
+
+// erroredSnapshots returns a completed snapshot and a
+// newer one in the error state.
+func erroredSnapshots() []*ec2.Snapshot {
+	return []*ec2.Snapshot{
+		{
+			SnapshotId: aws.String("snap-001"),
+			StartTime:  aws.Time(now().Add(-2 * time.Hour)),
+			State:      aws.String("completed"),
+		},
+		{
+			SnapshotId: aws.String("snap-002"),
+			StartTime:  aws.Time(now().Add(-time.Minute)),
+			State:      aws.String("error"),
+		},
+	}
+}
+
+func TestParseErrorPolicy(t *testing.T) {
+	assert := assert.New(t)
+
+	for s, want := range map[string]ErrorPolicy{
+		"":       ErrorKeep,
+		"keep":   ErrorKeep,
+		"delete": ErrorDelete,
+		"tag":    ErrorTag,
+	} {
+		p, err := ParseErrorPolicy(s)
+		assert.NoError(err)
+		assert.Equal(want, p)
+	}
+
+	_, err := ParseErrorPolicy("drop")
+	assert.EqualError(err, `invalid error snapshot policy "drop"`)
+}
+
+func TestErroredKeep(t *testing.T) {
+	assert := assert.New(t)
+	defer clock(time.Unix(86400, 0))()
+
+	e := New(Config{
+		Limit:    2,
+		Interval: time.Hour,
+		EC2: mock{
+			DescribeSnapshotsFunc: func(req *ec2.DescribeSnapshotsInput) (*ec2.DescribeSnapshotsOutput, error) {
+				return &ec2.DescribeSnapshotsOutput{Snapshots: erroredSnapshots()}, nil
+			},
+			CreateSnapshotFunc: func(*ec2.CreateSnapshotInput) (*ec2.Snapshot, error) {
+				return &ec2.Snapshot{SnapshotId: aws.String("snap-003")}, nil
+			},
+		},
+	})
+
+	res := e.backup(Volume{ID: "vol-xyz"})
+
+	assert.NoError(res.Err)
+	assert.Equal("", res.Skipped)
+	assert.Equal("snap-003", res.CreatedSnapshot)
+	assert.Equal([]string{"snap-002"}, res.ErroredSnapshots)
+	assert.Empty(res.DeletedSnapshots)
+}
+
+func TestErroredDelete(t *testing.T) {
+	assert := assert.New(t)
+	defer clock(time.Unix(86400, 0))()
+
+	var deleted []string
+
+	e := New(Config{
+		Limit:          2,
+		ErrorSnapshots: ErrorDelete,
+		EC2: mock{
+			DescribeSnapshotsFunc: func(req *ec2.DescribeSnapshotsInput) (*ec2.DescribeSnapshotsOutput, error) {
+				return &ec2.DescribeSnapshotsOutput{Snapshots: erroredSnapshots()}, nil
+			},
+			CreateSnapshotFunc: func(*ec2.CreateSnapshotInput) (*ec2.Snapshot, error) {
+				return &ec2.Snapshot{SnapshotId: aws.String("snap-003")}, nil
+			},
+			DeleteSnapshotFunc: func(req *ec2.DeleteSnapshotInput) (*ec2.DeleteSnapshotOutput, error) {
+				deleted = append(deleted, *req.SnapshotId)
+				return &ec2.DeleteSnapshotOutput{}, nil
+			},
+		},
+	})
+
+	res := e.backup(Volume{ID: "vol-xyz"})
+
+	assert.NoError(res.Err)
+	assert.Equal([]string{"snap-002"}, deleted)
+	assert.Equal([]string{"snap-002"}, res.DeletedSnapshots)
+	assert.Equal([]string{"snap-002"}, res.ErroredSnapshots)
+}
+
+func TestErroredTag(t *testing.T) {
+	assert := assert.New(t)
+	defer clock(time.Unix(86400, 0))()
+
+	var tagged *ec2.CreateTagsInput
+
+	e := New(Config{
+		Limit:          2,
+		ErrorSnapshots: ErrorTag,
+		EC2: mock{
+			DescribeSnapshotsFunc: func(req *ec2.DescribeSnapshotsInput) (*ec2.DescribeSnapshotsOutput, error) {
+				set := erroredSnapshots()
+				set = append(set, &ec2.Snapshot{
+					SnapshotId: aws.String("snap-000"),
+					StartTime:  aws.Time(now().Add(-time.Hour)),
+					State:      aws.String("error"),
+					Tags:       []*ec2.Tag{{Key: aws.String(tagError), Value: aws.String("1970-01-01T00:00:00Z")}},
+				})
+				return &ec2.DescribeSnapshotsOutput{Snapshots: set}, nil
+			},
+			CreateSnapshotFunc: func(*ec2.CreateSnapshotInput) (*ec2.Snapshot, error) {
+				return &ec2.Snapshot{SnapshotId: aws.String("snap-003")}, nil
+			},
+			CreateTagsFunc: func(req *ec2.CreateTagsInput) (*ec2.CreateTagsOutput, error) {
+				tagged = req
+				return &ec2.CreateTagsOutput{}, nil
+			},
+		},
+	})
+
+	res := e.backup(Volume{ID: "vol-xyz"})
+
+	assert.NoError(res.Err)
+	assert.Empty(res.DeletedSnapshots)
+	assert.Equal([]string{"snap-002", "snap-000"}, res.ErroredSnapshots)
+	assert.Equal([]*string{aws.String("snap-002")}, tagged.Resources)
+	assert.Equal("1970-01-02T00:00:00Z", *tagged.Tags[0].Value)
+}
+
+func TestErroredDryRun(t *testing.T) {
+	assert := assert.New(t)
+	defer clock(time.Unix(86400, 0))()
+
+	e := New(Config{
+		Limit:          2,
+		ErrorSnapshots: ErrorDelete,
+		DryRun:         true,
+		EC2: mock{
+			DescribeSnapshotsFunc: func(req *ec2.DescribeSnapshotsInput) (*ec2.DescribeSnapshotsOutput, error) {
+				return &ec2.DescribeSnapshotsOutput{Snapshots: erroredSnapshots()}, nil
+			},
+		},
+	})
+
+	res := e.backup(Volume{ID: "vol-xyz"})
+
+	assert.NoError(res.Err)
+	assert.Equal([]string{"snap-002"}, res.DeletedSnapshots)
+}
@@ -0,0 +1,144 @@
+package engine
+
+import (
+	"errors"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+)
+
+// Phase is the phase of a run in which an error happened.
+type Phase string
+
+// Phases.
+const (
+	PhasePolicy      Phase = "policy"
+	PhaseLock        Phase = "lock"
+	PhaseDescribe    Phase = "describe"
+	PhasePending     Phase = "pending"
+	PhaseCreate      Phase = "create"
+	PhaseTags        Phase = "tags"
+	PhasePrune       Phase = "prune"
+	PhaseCleanup     Phase = "cleanup"
+	PhaseArchive     Phase = "archive"
+	PhaseFastRestore Phase = "fast-restore"
+	PhaseReplicate   Phase = "replicate"
+	PhaseImage       Phase = "image"
+)
+
+// Kinds of errors, usable with `errors.Is` on any
+// error in `Result.Err`.
+var (
+	ErrPending          = errors.New("volume has a snapshot in pending state")
+	ErrThrottled        = errors.New("request throttled")
+	ErrPermissionDenied = errors.New("permission denied")
+	ErrVolumeGone       = errors.New("volume not found")
+	ErrTagCopy          = errors.New("tag copy failed")
+	ErrPrune            = errors.New("prune failed")
+)
+
+// Error codes of the error kinds.
+var codes = []struct {
+	err  error
+	code string
+}{
+	{ErrPending, "pending"},
+	{ErrThrottled, "throttled"},
+	{ErrPermissionDenied, "permission-denied"},
+	{ErrVolumeGone, "volume-gone"},
+	{ErrTagCopy, "tag-copy"},
+	{ErrPrune, "prune"},
+}
+
+// Error is the error of a run, with the phase it happened in.
+//
+// Its kind is either set explicitly, e.g. `ErrPrune`, or
+// derived from the AWS error code of `Err`, e.g. `ErrThrottled`.
+type Error struct {
+	Phase Phase
+	Kind  error
+	Err   error
+}
+
+// Error implements error.
+func (e *Error) Error() string {
+	if e.Kind != nil {
+		return e.Kind.Error() + ": " + e.Err.Error()
+	}
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is returns true if `target` is the kind of the error.
+func (e *Error) Is(target error) bool {
+	return target != nil && (target == e.Kind || target == classify(e.Err))
+}
+
+// fail returns `err` as an error of phase `p` and `kind`,
+// which may be nil. A nil `err` returns nil.
+func fail(p Phase, kind, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var e *Error
+	if errors.As(err, &e) {
+		return err
+	}
+
+	return &Error{Phase: p, Kind: kind, Err: err}
+}
+
+// classify returns the kind of the AWS error `err`, or nil.
+func classify(err error) error {
+	var aerr awserr.Error
+	if !errors.As(err, &aerr) {
+		return nil
+	}
+
+	switch aerr.Code() {
+	case "Throttling", "ThrottlingException", "RequestLimitExceeded",
+		"SnapshotCreationPerVolumeRateExceeded":
+		return ErrThrottled
+	case "UnauthorizedOperation", "AccessDenied", "AccessDeniedException":
+		return ErrPermissionDenied
+	case "InvalidVolume.NotFound":
+		return ErrVolumeGone
+	default:
+		return nil
+	}
+}
+
+// ErrorPhase returns the phase in which `err` happened, if known.
+func ErrorPhase(err error) Phase {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Phase
+	}
+	return ""
+}
+
+// ErrorCode returns the code of the kind of `err`, e.g. "throttled",
+// "unknown" if it is of no known kind and "" if `err` is nil.
+func ErrorCode(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	for _, c := range codes {
+		if errors.Is(err, c.err) {
+			return c.code
+		}
+	}
+
+	return "unknown"
+}
+
+// Retryable returns true if a run that failed with `err`
+// may succeed when retried later.
+func Retryable(err error) bool {
+	return errors.Is(err, ErrPending) || errors.Is(err, ErrThrottled)
+}
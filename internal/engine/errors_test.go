@@ -0,0 +1,78 @@
+package engine
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorKinds(t *testing.T) {
+	assert := assert.New(t)
+
+	cases := []struct {
+		err  error
+		kind error
+		code string
+	}{
+		{fail(PhaseCreate, nil, awserr.New("RequestLimitExceeded", "slow down", nil)), ErrThrottled, "throttled"},
+		{fail(PhaseDescribe, nil, awserr.New("UnauthorizedOperation", "no", nil)), ErrPermissionDenied, "permission-denied"},
+		{fail(PhaseCreate, nil, awserr.New("InvalidVolume.NotFound", "gone", nil)), ErrVolumeGone, "volume-gone"},
+		{fail(PhaseTags, ErrTagCopy, errors.New("boom")), ErrTagCopy, "tag-copy"},
+		{fail(PhasePrune, ErrPrune, DeleteErrors{{SnapshotID: "snap-001", Err: errors.New("boom")}}), ErrPrune, "prune"},
+	}
+
+	for _, c := range cases {
+		assert.True(errors.Is(c.err, c.kind), c.err.Error())
+		assert.Equal(c.code, ErrorCode(c.err))
+	}
+
+	assert.Equal("", ErrorCode(nil))
+	assert.Equal("unknown", ErrorCode(errors.New("boom")))
+	assert.Equal("unknown", ErrorCode(fail(PhaseCreate, nil, errors.New("boom"))))
+}
+
+func TestErrorMessage(t *testing.T) {
+	assert := assert.New(t)
+
+	err := fail(PhasePrune, ErrPrune, errors.New("boom"))
+	assert.EqualError(err, "prune failed: boom")
+	assert.Equal(PhasePrune, ErrorPhase(err))
+	assert.Equal(Phase(""), ErrorPhase(errors.New("boom")))
+	assert.Nil(fail(PhasePrune, ErrPrune, nil))
+
+	// The innermost phase is kept.
+	assert.Equal(PhaseDescribe, ErrorPhase(fail(PhasePending, nil, fail(PhaseDescribe, nil, errors.New("boom")))))
+}
+
+func TestRetryable(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.True(Retryable(fail(PhaseCreate, nil, awserr.New("Throttling", "slow down", nil))))
+	assert.False(Retryable(fail(PhaseCreate, nil, awserr.New("AccessDenied", "no", nil))))
+	assert.False(Retryable(errors.New("boom")))
+}
+
+func TestBackupVolumeGone(t *testing.T) {
+	assert := assert.New(t)
+
+	e := New(Config{
+		Limit: 2,
+		EC2: mock{
+			DescribeSnapshotsFunc: func(*ec2.DescribeSnapshotsInput) (*ec2.DescribeSnapshotsOutput, error) {
+				return &ec2.DescribeSnapshotsOutput{}, nil
+			},
+			CreateSnapshotFunc: func(*ec2.CreateSnapshotInput) (*ec2.Snapshot, error) {
+				return nil, awserr.New("InvalidVolume.NotFound", "The volume 'vol-xyz' does not exist.", nil)
+			},
+		},
+	})
+
+	res := e.backup(Volume{ID: "vol-xyz"})
+
+	assert.True(errors.Is(res.Err, ErrVolumeGone))
+	assert.Equal(PhaseCreate, ErrorPhase(res.Err))
+	assert.Equal("volume-gone", ErrorCode(res.Err))
+}
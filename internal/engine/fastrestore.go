@@ -0,0 +1,161 @@
+package engine
+
+import (
+	"strings"
+)
+
+// Tag set on the snapshot of a volume with fast restores
+// enabled, its value is the comma separated list of zones.
+const tagFastRestore = "ebs-backup:fast-restore"
+
+// fastRestore enables fast restores in the policy zones on the
+// newest completed snapshot of `set`, and disables them on the
+// other snapshots of the volume in `all` that have them, so that
+// a single snapshot of the volume has them enabled. The provider
+// must be a FastRestorer, e.g. EBS Fast Snapshot Restore.
+//
+// The zones of the snapshot are reconciled with its tag when the
+// policy changed, it is enabled in the new zones and disabled in
+// those that were removed. Snapshots on hold or protected must be
+// part of `all`, they keep no fast restores either.
+//
+// When the policy has no zones, e.g. its tag was removed, fast
+// restores are disabled on every snapshot of `all` that has them.
+//
+// It returns the id of the snapshot with fast restores
+// enabled and the ids of those they were disabled on.
+func (e *Engine) fastRestore(p policy, set, all []Snapshot) (string, []string, error) {
+	prov := e.provider()
+
+	f, ok := prov.(FastRestorer)
+	if !ok {
+		return "", nil, ErrNotSupported
+	}
+
+	var completed []Snapshot
+
+	for _, s := range set {
+		if s.State == SnapshotCompleted {
+			completed = append(completed, s)
+		}
+	}
+
+	var id string
+
+	if len(p.FastZones) > 0 {
+		target := newest(completed)
+		if target == nil {
+			return "", nil, nil
+		}
+		id = target.ID
+
+		value := target.Tags[tagFastRestore]
+		have := zones(value)
+		enable := subtract(p.FastZones, have)
+		disable := subtract(have, p.FastZones)
+
+		if len(enable) > 0 {
+			if err := f.EnableFastRestore(id, enable); err != nil {
+				return "", nil, err
+			}
+		}
+
+		if len(disable) > 0 {
+			if err := f.DisableFastRestore(id, disable); err != nil {
+				return "", nil, err
+			}
+		}
+
+		if len(enable) > 0 || len(disable) > 0 {
+			err := prov.TagSnapshot(id, map[string]string{
+				tagFastRestore: strings.Join(p.FastZones, ","),
+			})
+			if err != nil {
+				return "", nil, err
+			}
+		}
+	}
+
+	var disabled []string
+
+	for _, s := range all {
+		value, ok := s.Tags[tagFastRestore]
+		if !ok || s.ID == id {
+			continue
+		}
+
+		if err := f.DisableFastRestore(s.ID, zones(value)); err != nil {
+			return id, disabled, err
+		}
+
+		if err := f.UntagSnapshot(s.ID, tagFastRestore); err != nil {
+			return id, disabled, err
+		}
+
+		disabled = append(disabled, s.ID)
+	}
+
+	return id, disabled, nil
+}
+
+// restored reports whether a snapshot of `set` has fast restores enabled.
+func restored(set []Snapshot) bool {
+	for _, s := range set {
+		if _, ok := s.Tags[tagFastRestore]; ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// zones returns the zones of the `ebs-backup:fast-restore` tag `value`.
+func zones(value string) []string {
+	var ret []string
+
+	for _, z := range strings.Split(value, ",") {
+		if z != "" {
+			ret = append(ret, z)
+		}
+	}
+
+	return ret
+}
+
+// subtract returns the strings of `a` that are not in `b`.
+func subtract(a, b []string) []string {
+	var ret []string
+
+	for _, s := range a {
+		found := false
+		for _, t := range b {
+			if s == t {
+				found = true
+				break
+			}
+		}
+		if !found {
+			ret = append(ret, s)
+		}
+	}
+
+	return ret
+}
+
+// without returns the snapshots of `set` whose id is not in `ids`.
+func without(set []Snapshot, ids []string) []Snapshot {
+	skip := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		skip[id] = true
+	}
+
+	var ret []Snapshot
+
+	for _, s := range set {
+		if !skip[s.ID] {
+			ret = append(ret, s)
+		}
+	}
+
+	return ret
+}
@@ -0,0 +1,211 @@
+package engine
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackupFastRestore(t *testing.T) {
+	assert := assert.New(t)
+	defer clock(time.Unix(86400, 0))()
+
+	var enabled *ec2.EnableFastSnapshotRestoresInput
+	var disabled []*ec2.DisableFastSnapshotRestoresInput
+	var untagged []string
+
+	fast := &ec2.Tag{Key: aws.String(tagFastRestore), Value: aws.String("us-east-1a")}
+
+	e := New(Config{
+		Limit:            2,
+		FastRestoreZones: []string{"us-east-1a", "us-east-1b"},
+		EC2: mock{
+			DescribeSnapshotsFunc: func(req *ec2.DescribeSnapshotsInput) (*ec2.DescribeSnapshotsOutput, error) {
+				return &ec2.DescribeSnapshotsOutput{
+					Snapshots: []*ec2.Snapshot{
+						managedSnapshot("snap-001", "vol-xyz", 3*time.Hour, fast),
+						managedSnapshot("snap-002", "vol-xyz", 2*time.Hour, fast),
+						managedSnapshot("snap-003", "vol-xyz", time.Hour),
+					},
+				}, nil
+			},
+			CreateSnapshotFunc: func(*ec2.CreateSnapshotInput) (*ec2.Snapshot, error) {
+				return &ec2.Snapshot{
+					SnapshotId: aws.String("snap-004"),
+					StartTime:  aws.Time(now()),
+					State:      aws.String("pending"),
+				}, nil
+			},
+			DeleteSnapshotFunc: func(req *ec2.DeleteSnapshotInput) (*ec2.DeleteSnapshotOutput, error) {
+				return &ec2.DeleteSnapshotOutput{}, nil
+			},
+			EnableFastSnapshotRestoresFunc: func(req *ec2.EnableFastSnapshotRestoresInput) (*ec2.EnableFastSnapshotRestoresOutput, error) {
+				enabled = req
+				return &ec2.EnableFastSnapshotRestoresOutput{}, nil
+			},
+			DisableFastSnapshotRestoresFunc: func(req *ec2.DisableFastSnapshotRestoresInput) (*ec2.DisableFastSnapshotRestoresOutput, error) {
+				disabled = append(disabled, req)
+				return &ec2.DisableFastSnapshotRestoresOutput{}, nil
+			},
+			CreateTagsFunc: func(req *ec2.CreateTagsInput) (*ec2.CreateTagsOutput, error) {
+				assert.Equal("us-east-1a,us-east-1b", *req.Tags[0].Value)
+				return &ec2.CreateTagsOutput{}, nil
+			},
+			DeleteTagsFunc: func(req *ec2.DeleteTagsInput) (*ec2.DeleteTagsOutput, error) {
+				untagged = append(untagged, *req.Resources[0])
+				return &ec2.DeleteTagsOutput{}, nil
+			},
+		},
+	})
+
+	res := e.backup(Volume{ID: "vol-xyz"})
+
+	assert.NoError(res.Err)
+	assert.Equal([]string{"snap-002", "snap-001"}, res.DeletedSnapshots)
+	assert.Equal("snap-003", res.FastRestoreSnapshot)
+	assert.Equal([]*string{aws.String("snap-003")}, enabled.SourceSnapshotIds)
+	assert.Equal([]*string{aws.String("us-east-1a"), aws.String("us-east-1b")}, enabled.AvailabilityZones)
+	assert.Empty(disabled)
+	assert.Empty(res.FastRestoreDisabled)
+}
+
+func TestFastRestoreDisable(t *testing.T) {
+	assert := assert.New(t)
+	defer clock(time.Unix(86400, 0))()
+
+	var disabled *ec2.DisableFastSnapshotRestoresInput
+
+	fast := &ec2.Tag{Key: aws.String(tagFastRestore), Value: aws.String("us-east-1a,us-east-1b")}
+	prev := managedSnapshot("snap-001", "vol-xyz", 2*time.Hour, fast)
+	next := managedSnapshot("snap-002", "vol-xyz", time.Hour,
+		&ec2.Tag{Key: aws.String(tagFastRestore), Value: aws.String("us-east-1a")})
+
+	e := New(Config{
+		EC2: mock{
+			DisableFastSnapshotRestoresFunc: func(req *ec2.DisableFastSnapshotRestoresInput) (*ec2.DisableFastSnapshotRestoresOutput, error) {
+				disabled = req
+				return &ec2.DisableFastSnapshotRestoresOutput{}, nil
+			},
+			DeleteTagsFunc: func(req *ec2.DeleteTagsInput) (*ec2.DeleteTagsOutput, error) {
+				return &ec2.DeleteTagsOutput{}, nil
+			},
+		},
+	})
+
+	set := []Snapshot{snapshot(prev), snapshot(next)}
+	id, off, err := e.fastRestore(policy{FastZones: []string{"us-east-1a"}}, set, set)
+	assert.NoError(err)
+	assert.Equal("snap-002", id)
+	assert.Equal([]string{"snap-001"}, off)
+	assert.Equal([]*string{aws.String("us-east-1a"), aws.String("us-east-1b")}, disabled.AvailabilityZones)
+}
+
+func TestFastRestoreZonesChanged(t *testing.T) {
+	assert := assert.New(t)
+	defer clock(time.Unix(86400, 0))()
+
+	var enabled *ec2.EnableFastSnapshotRestoresInput
+	var disabled *ec2.DisableFastSnapshotRestoresInput
+	var tagged *ec2.CreateTagsInput
+
+	fast := &ec2.Tag{Key: aws.String(tagFastRestore), Value: aws.String("us-east-1a,us-east-1b")}
+	s := managedSnapshot("snap-001", "vol-xyz", time.Hour, fast)
+
+	e := New(Config{
+		EC2: mock{
+			EnableFastSnapshotRestoresFunc: func(req *ec2.EnableFastSnapshotRestoresInput) (*ec2.EnableFastSnapshotRestoresOutput, error) {
+				enabled = req
+				return &ec2.EnableFastSnapshotRestoresOutput{}, nil
+			},
+			DisableFastSnapshotRestoresFunc: func(req *ec2.DisableFastSnapshotRestoresInput) (*ec2.DisableFastSnapshotRestoresOutput, error) {
+				disabled = req
+				return &ec2.DisableFastSnapshotRestoresOutput{}, nil
+			},
+			CreateTagsFunc: func(req *ec2.CreateTagsInput) (*ec2.CreateTagsOutput, error) {
+				tagged = req
+				return &ec2.CreateTagsOutput{}, nil
+			},
+		},
+	})
+
+	set := []Snapshot{snapshot(s)}
+	id, off, err := e.fastRestore(policy{FastZones: []string{"us-east-1b", "us-east-1c"}}, set, set)
+	assert.NoError(err)
+	assert.Equal("snap-001", id)
+	assert.Empty(off)
+	assert.Equal([]*string{aws.String("us-east-1c")}, enabled.AvailabilityZones)
+	assert.Equal([]*string{aws.String("us-east-1a")}, disabled.AvailabilityZones)
+	assert.Equal("us-east-1b,us-east-1c", *tagged.Tags[0].Value)
+
+	enabled, disabled, tagged = nil, nil, nil
+	s.Tags = []*ec2.Tag{{Key: aws.String(tagFastRestore), Value: aws.String("us-east-1b,us-east-1c")}}
+	set = []Snapshot{snapshot(s)}
+
+	_, _, err = e.fastRestore(policy{FastZones: []string{"us-east-1c", "us-east-1b"}}, set, set)
+	assert.NoError(err)
+	assert.Nil(enabled)
+	assert.Nil(disabled)
+	assert.Nil(tagged)
+}
+
+func TestFastRestoreZonesRemoved(t *testing.T) {
+	assert := assert.New(t)
+	defer clock(time.Unix(86400, 0))()
+
+	var disabled *ec2.DisableFastSnapshotRestoresInput
+	var untagged []string
+
+	fast := &ec2.Tag{Key: aws.String(tagFastRestore), Value: aws.String("us-east-1a,us-east-1b")}
+
+	e := New(Config{
+		Limit: 3,
+		EC2: mock{
+			DescribeSnapshotsFunc: func(req *ec2.DescribeSnapshotsInput) (*ec2.DescribeSnapshotsOutput, error) {
+				return &ec2.DescribeSnapshotsOutput{
+					Snapshots: []*ec2.Snapshot{
+						managedSnapshot("snap-001", "vol-xyz", time.Hour, fast),
+					},
+				}, nil
+			},
+			CreateSnapshotFunc: func(*ec2.CreateSnapshotInput) (*ec2.Snapshot, error) {
+				return &ec2.Snapshot{
+					SnapshotId: aws.String("snap-002"),
+					StartTime:  aws.Time(now()),
+					State:      aws.String("pending"),
+				}, nil
+			},
+			DisableFastSnapshotRestoresFunc: func(req *ec2.DisableFastSnapshotRestoresInput) (*ec2.DisableFastSnapshotRestoresOutput, error) {
+				disabled = req
+				return &ec2.DisableFastSnapshotRestoresOutput{}, nil
+			},
+			DeleteTagsFunc: func(req *ec2.DeleteTagsInput) (*ec2.DeleteTagsOutput, error) {
+				untagged = append(untagged, *req.Resources[0])
+				return &ec2.DeleteTagsOutput{}, nil
+			},
+		},
+	})
+
+	res := e.backup(Volume{ID: "vol-xyz"})
+
+	assert.NoError(res.Err)
+	assert.Empty(res.FastRestoreSnapshot)
+	assert.Equal([]string{"snap-001"}, res.FastRestoreDisabled)
+	assert.Equal([]*string{aws.String("us-east-1a"), aws.String("us-east-1b")}, disabled.AvailabilityZones)
+	assert.Equal([]string{"snap-001"}, untagged)
+}
+
+func TestPolicyFastRestoreZones(t *testing.T) {
+	assert := assert.New(t)
+
+	e := New(Config{FastRestoreZones: []string{"us-east-1a"}})
+
+	p, err := e.policy(Volume{Tags: map[string]string{tagFastZone: "us-east-1b, us-east-1c"}})
+	assert.NoError(err)
+	assert.Equal([]string{"us-east-1b", "us-east-1c"}, p.FastZones)
+
+	_, err = e.policy(Volume{Tags: map[string]string{tagFastZone: ","}})
+	assert.EqualError(err, `invalid ebs-backup:fast-restore-zones tag ","`)
+}
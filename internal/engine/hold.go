@@ -0,0 +1,157 @@
+package engine
+
+import (
+	"fmt"
+	"sort"
+	"time"
+	"unicode/utf8"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+// Tags of snapshots on hold, a held snapshot is never deleted.
+//
+// `ebs-backup:hold` holds the reason and `ebs-backup:hold-by` who
+// placed the hold and when. On release both are removed and
+// `ebs-backup:released` records who released the hold, when,
+// and its reason.
+const (
+	tagHold     = "ebs-backup:hold"
+	tagHoldBy   = "ebs-backup:hold-by"
+	tagReleased = "ebs-backup:released"
+)
+
+// maxTagValue is the maximum number of characters of a tag value.
+const maxTagValue = 256
+
+// Hold is a snapshot on hold.
+type Hold struct {
+	SnapshotID string
+	VolumeID   string
+	Reason     string
+	By         string
+}
+
+// held splits `set` into the snapshots that are not on hold and
+// those on hold.
+func held(set []Snapshot) (free, held []Snapshot) {
+	for _, s := range set {
+		if _, ok := s.Tags[tagHold]; ok {
+			held = append(held, s)
+			continue
+		}
+
+		free = append(free, s)
+	}
+
+	return free, held
+}
+
+// Hold places the snapshots `ids` on hold for `reason`, at most
+// `maxTagValue` characters, `by` identifies who placed the hold.
+func (e *Engine) Hold(reason, by string, ids ...string) error {
+	if reason == "" {
+		return fmt.Errorf("a hold requires a reason")
+	}
+
+	if n := utf8.RuneCountInString(reason); n > maxTagValue {
+		return fmt.Errorf("the reason of a hold is %d characters, the maximum is %d", n, maxTagValue)
+	}
+
+	_, err := e.EC2.CreateTags(&ec2.CreateTagsInput{
+		Resources: aws.StringSlice(ids),
+		Tags: []*ec2.Tag{
+			{Key: aws.String(tagHold), Value: aws.String(reason)},
+			{Key: aws.String(tagHoldBy), Value: aws.String(audit(by, ""))},
+		},
+	})
+	return err
+}
+
+// Release releases the holds of the snapshots `ids`,
+// `by` identifies who released them.
+func (e *Engine) Release(by string, ids ...string) error {
+	resp, err := e.EC2.DescribeSnapshots(&ec2.DescribeSnapshotsInput{
+		SnapshotIds: aws.StringSlice(ids),
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, s := range resp.Snapshots {
+		reason, ok := tag(s.Tags, tagHold)
+		if !ok {
+			continue
+		}
+
+		_, err := e.EC2.CreateTags(&ec2.CreateTagsInput{
+			Resources: []*string{s.SnapshotId},
+			Tags: []*ec2.Tag{
+				{Key: aws.String(tagReleased), Value: aws.String(audit(by, reason))},
+			},
+		})
+		if err != nil {
+			return err
+		}
+
+		_, err = e.EC2.DeleteTags(&ec2.DeleteTagsInput{
+			Resources: []*string{s.SnapshotId},
+			Tags: []*ec2.Tag{
+				{Key: aws.String(tagHold)},
+				{Key: aws.String(tagHoldBy)},
+			},
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Holds returns the snapshots on hold sorted by snapshot id.
+func (e *Engine) Holds() ([]Hold, error) {
+	resp, err := e.EC2.DescribeSnapshots(&ec2.DescribeSnapshotsInput{
+		OwnerIds: aws.StringSlice([]string{"self"}),
+		Filters:  []*ec2.Filter{filter("tag-key", tagHold)},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make([]Hold, 0, len(resp.Snapshots))
+
+	for _, s := range resp.Snapshots {
+		reason, _ := tag(s.Tags, tagHold)
+		by, _ := tag(s.Tags, tagHoldBy)
+
+		ret = append(ret, Hold{
+			SnapshotID: aws.StringValue(s.SnapshotId),
+			VolumeID:   aws.StringValue(s.VolumeId),
+			Reason:     reason,
+			By:         by,
+		})
+	}
+
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i].SnapshotID < ret[j].SnapshotID
+	})
+
+	return ret, nil
+}
+
+// audit returns an audit tag value for `by` at the current time,
+// followed by `reason` if any, truncated to the tag value limit.
+func audit(by, reason string) string {
+	v := by + " " + now().UTC().Format(time.RFC3339)
+	if reason != "" {
+		v += ": " + reason
+	}
+
+	if r := []rune(v); len(r) > maxTagValue {
+		v = string(r[:maxTagValue])
+	}
+
+	return v
+}
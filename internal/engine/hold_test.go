@@ -0,0 +1,127 @@
+package engine
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/stretchr/testify/assert"
+)
+
+var holdTag = &ec2.Tag{Key: aws.String(tagHold), Value: aws.String("INC-42")}
+
+func TestBackupHold(t *testing.T) {
+	assert := assert.New(t)
+	defer clock(time.Unix(86400, 0))()
+
+	var deleted []string
+
+	e := New(Config{
+		Limit: 2,
+		EC2: mock{
+			DescribeSnapshotsFunc: func(req *ec2.DescribeSnapshotsInput) (*ec2.DescribeSnapshotsOutput, error) {
+				return &ec2.DescribeSnapshotsOutput{
+					Snapshots: []*ec2.Snapshot{
+						managedSnapshot("snap-001", "vol-xyz", 4*time.Hour, holdTag),
+						managedSnapshot("snap-002", "vol-xyz", 3*time.Hour),
+						managedSnapshot("snap-003", "vol-xyz", 2*time.Hour),
+					},
+				}, nil
+			},
+			CreateSnapshotFunc: func(*ec2.CreateSnapshotInput) (*ec2.Snapshot, error) {
+				return &ec2.Snapshot{SnapshotId: aws.String("snap-004"), StartTime: aws.Time(now())}, nil
+			},
+			DeleteSnapshotFunc: func(req *ec2.DeleteSnapshotInput) (*ec2.DeleteSnapshotOutput, error) {
+				deleted = append(deleted, *req.SnapshotId)
+				return &ec2.DeleteSnapshotOutput{}, nil
+			},
+		},
+	})
+
+	res := e.backup(Volume{ID: "vol-xyz"})
+
+	assert.NoError(res.Err)
+	assert.Equal([]string{"snap-001"}, res.HeldSnapshots)
+	assert.Equal([]string{"snap-002"}, res.DeletedSnapshots)
+	assert.Equal([]string{"snap-002"}, deleted)
+}
+
+func TestDeleteHeld(t *testing.T) {
+	assert := assert.New(t)
+
+	e := New(Config{
+		EC2: mock{
+			DeleteSnapshotFunc: func(req *ec2.DeleteSnapshotInput) (*ec2.DeleteSnapshotOutput, error) {
+				assert.Equal("snap-002", *req.SnapshotId)
+				return &ec2.DeleteSnapshotOutput{}, nil
+			},
+		},
+	})
+
+	ids, err := e.delete(e.ec2Provider(), []Snapshot{
+		snapshot(&ec2.Snapshot{SnapshotId: aws.String("snap-001"), Tags: []*ec2.Tag{holdTag}}),
+		{ID: "snap-002"},
+	})
+	assert.NoError(err)
+	assert.Equal([]string{"snap-002"}, ids)
+}
+
+func TestHoldRelease(t *testing.T) {
+	assert := assert.New(t)
+	defer clock(time.Unix(0, 0))()
+
+	var created []*ec2.CreateTagsInput
+	var removed *ec2.DeleteTagsInput
+
+	e := New(Config{
+		EC2: mock{
+			DescribeSnapshotsFunc: func(req *ec2.DescribeSnapshotsInput) (*ec2.DescribeSnapshotsOutput, error) {
+				assert.Equal([]*string{aws.String("snap-001"), aws.String("snap-002")}, req.SnapshotIds)
+				return &ec2.DescribeSnapshotsOutput{
+					Snapshots: []*ec2.Snapshot{
+						{SnapshotId: aws.String("snap-001"), Tags: []*ec2.Tag{holdTag}},
+						{SnapshotId: aws.String("snap-002")},
+					},
+				}, nil
+			},
+			CreateTagsFunc: func(req *ec2.CreateTagsInput) (*ec2.CreateTagsOutput, error) {
+				created = append(created, req)
+				return &ec2.CreateTagsOutput{}, nil
+			},
+			DeleteTagsFunc: func(req *ec2.DeleteTagsInput) (*ec2.DeleteTagsOutput, error) {
+				removed = req
+				return &ec2.DeleteTagsOutput{}, nil
+			},
+		},
+	})
+
+	assert.EqualError(e.Hold("", "alice", "snap-001"), "a hold requires a reason")
+	assert.EqualError(e.Hold(strings.Repeat("é", 257), "alice", "snap-001"),
+		"the reason of a hold is 257 characters, the maximum is 256")
+	assert.Empty(created)
+
+	assert.NoError(e.Hold("INC-42", "alice", "snap-001"))
+	assert.Equal([]*ec2.Tag{
+		{Key: aws.String(tagHold), Value: aws.String("INC-42")},
+		{Key: aws.String(tagHoldBy), Value: aws.String("alice 1970-01-01T00:00:00Z")},
+	}, created[0].Tags)
+
+	assert.NoError(e.Release("bob", "snap-001", "snap-002"))
+	assert.Equal(2, len(created))
+	assert.Equal([]*string{aws.String("snap-001")}, created[1].Resources)
+	assert.Equal("bob 1970-01-01T00:00:00Z: INC-42", *created[1].Tags[0].Value)
+	assert.Equal([]*string{aws.String("snap-001")}, removed.Resources)
+}
+
+func TestAudit(t *testing.T) {
+	assert := assert.New(t)
+	defer clock(time.Unix(0, 0))()
+
+	v := audit("alice", strings.Repeat("é", 300))
+	assert.Equal(maxTagValue, utf8.RuneCountInString(v))
+	assert.True(utf8.ValidString(v))
+	assert.True(strings.HasPrefix(v, "alice 1970-01-01T00:00:00Z: éé"))
+}
@@ -0,0 +1,205 @@
+package engine
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+// Tag set on AMIs and their snapshots to the id of the backed up instance.
+const tagInstance = "ebs-backup:instance"
+
+// Tag set on the snapshots of AMIs to the name of the AMI, they
+// are not volume backups and are left out of their rotation,
+// reaping, checks and reports, see `fromImage`.
+const tagImage = "ebs-backup:image"
+
+// imageDescription is the description template of the AMIs.
+const imageDescription = "ebs-backup {{.Job}}: {{.InstanceID}}"
+
+// unsafe matches the characters not allowed in AMI names.
+var unsafe = regexp.MustCompile(`[^a-zA-Z0-9()\[\] ./'@_-]`)
+
+// images backs up the instances the `volumes` are attached to
+// with an AMI each, it returns a result per instance.
+func (e *Engine) images(volumes []Volume) []Result {
+	var instances []string
+	seen := make(map[string]bool)
+
+	for _, v := range volumes {
+		if id := v.InstanceID; id != "" && !seen[id] {
+			seen[id] = true
+			instances = append(instances, id)
+		}
+	}
+
+	sort.Strings(instances)
+	log.WithField("instances", len(instances)).Info("backup")
+
+	results := make([]Result, 0, len(instances))
+
+	for _, id := range instances {
+		start := time.Now()
+		res := e.image(id)
+		res.InstanceID = id
+		res.Duration = time.Since(start)
+
+		ctx := log.WithField("instance_id", id)
+		if res.Err != nil {
+			ctx.WithError(res.Err).Error("image")
+		} else {
+			ctx.WithField("image_id", res.CreatedImage).Info("image")
+		}
+
+		results = append(results, res)
+	}
+
+	return results
+}
+
+// image creates an AMI of instance `id` and rotates its AMIs.
+//
+// The AMI and its snapshots are tagged like volume snapshots
+// and with `ebs-backup:instance`, the snapshots also with
+// `ebs-backup:image`. AMIs beyond `.Limit` are
+// deregistered and their snapshots deleted.
+func (e *Engine) image(id string) Result {
+	var res Result
+
+	resp, err := e.EC2.DescribeImages(&ec2.DescribeImagesInput{
+		Owners: aws.StringSlice([]string{"self"}),
+		Filters: []*ec2.Filter{
+			filter("tag:"+tagInstance, id),
+			filter("tag:"+tagJob, e.Job),
+		},
+	})
+	if err != nil {
+		res.Err = fail(PhaseImage, nil, err)
+		return res
+	}
+
+	images := resp.Images
+	sort.Slice(images, func(i, j int) bool {
+		return aws.StringValue(images[i].CreationDate) > aws.StringValue(images[j].CreationDate)
+	})
+
+	if e.DryRun {
+		if len(images)+1 > e.Limit {
+			res.DeregisteredImages = imageIDs(images[e.Limit-1:])
+		}
+		return res
+	}
+
+	m := Meta{
+		InstanceID: id,
+		Job:        e.Job,
+		Time:       now().UTC(),
+		Version:    e.Version,
+	}
+
+	description, err := render(imageDescription, m)
+	if err != nil {
+		res.Err = fail(PhaseImage, nil, err)
+		return res
+	}
+
+	tags, err := e.tags(Volume{}, policy{}, m)
+	if err != nil {
+		res.Err = fail(PhaseTags, ErrTagCopy, err)
+		return res
+	}
+	tags[tagInstance] = id
+	imageTags := tagList(tags)
+
+	name := unsafe.ReplaceAllString(fmt.Sprintf("ebs-backup-%s-%s-%d", e.Job, id, m.Time.Unix()), "-")
+	tags[tagImage] = name
+
+	created, err := e.EC2.CreateImage(&ec2.CreateImageInput{
+		InstanceId:  aws.String(id),
+		Name:        aws.String(name),
+		Description: aws.String(description),
+		NoReboot:    aws.Bool(e.NoReboot),
+		TagSpecifications: append(
+			specs(ec2.ResourceTypeImage, imageTags),
+			specs(ec2.ResourceTypeSnapshot, tagList(tags))...,
+		),
+	})
+	if err != nil {
+		res.Err = fail(PhaseImage, nil, err)
+		return res
+	}
+	res.CreatedImage = aws.StringValue(created.ImageId)
+
+	if len(images)+1 > e.Limit {
+		for _, img := range images[e.Limit-1:] {
+			deleted, err := e.deregister(img)
+			res.DeletedSnapshots = append(res.DeletedSnapshots, deleted...)
+			if err != nil {
+				res.Err = fail(PhasePrune, ErrPrune, err)
+				return res
+			}
+			res.DeregisteredImages = append(res.DeregisteredImages, *img.ImageId)
+		}
+	}
+
+	return res
+}
+
+// deregister deregisters `img` and deletes its snapshots,
+// it returns the ids of the deleted snapshots.
+func (e *Engine) deregister(img *ec2.Image) ([]string, error) {
+	_, err := e.EC2.DeregisterImage(&ec2.DeregisterImageInput{
+		ImageId: img.ImageId,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []*string
+
+	for _, b := range img.BlockDeviceMappings {
+		if b.Ebs != nil && b.Ebs.SnapshotId != nil {
+			ids = append(ids, b.Ebs.SnapshotId)
+		}
+	}
+
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	resp, err := e.EC2.DescribeSnapshots(&ec2.DescribeSnapshotsInput{
+		SnapshotIds: ids,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	set := make([]Snapshot, 0, len(resp.Snapshots))
+	for _, s := range resp.Snapshots {
+		set = append(set, snapshot(s))
+	}
+
+	return e.delete(e.ec2Provider(), set)
+}
+
+// fromImage returns true if `s` is the snapshot of an AMI.
+func fromImage(s Snapshot) bool {
+	_, ok := s.Tags[tagImage]
+	return ok
+}
+
+// imageIDs returns the ids of `images`.
+func imageIDs(images []*ec2.Image) []string {
+	ret := make([]string, 0, len(images))
+
+	for _, img := range images {
+		ret = append(ret, *img.ImageId)
+	}
+
+	return ret
+}
@@ -0,0 +1,206 @@
+package engine
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/stretchr/testify/assert"
+)
+
+func image(id, snapshot string, age time.Duration) *ec2.Image {
+	return &ec2.Image{
+		ImageId:      aws.String(id),
+		CreationDate: aws.String(now().Add(-age).UTC().Format(time.RFC3339)),
+		BlockDeviceMappings: []*ec2.BlockDeviceMapping{
+			{Ebs: &ec2.EbsBlockDevice{SnapshotId: aws.String(snapshot)}},
+			{VirtualName: aws.String("ephemeral0")},
+		},
+	}
+}
+
+func TestImages(t *testing.T) {
+	assert := assert.New(t)
+	defer clock(time.Unix(86400, 0))()
+
+	var created *ec2.CreateImageInput
+	var deregistered, deleted []string
+
+	e := New(Config{
+		Name:     "db-*",
+		Limit:    2,
+		Images:   true,
+		NoReboot: true,
+		EC2: mock{
+			DescribeVolumesFunc: func(req *ec2.DescribeVolumesInput) (*ec2.DescribeVolumesOutput, error) {
+				attached := func(volume, instance string) *ec2.Volume {
+					return &ec2.Volume{
+						VolumeId:    aws.String(volume),
+						Attachments: []*ec2.VolumeAttachment{{InstanceId: aws.String(instance)}},
+					}
+				}
+
+				return &ec2.DescribeVolumesOutput{
+					Volumes: []*ec2.Volume{
+						attached("vol-001", "i-001"),
+						attached("vol-002", "i-001"),
+					},
+				}, nil
+			},
+			DescribeImagesFunc: func(req *ec2.DescribeImagesInput) (*ec2.DescribeImagesOutput, error) {
+				if *req.Filters[0].Name == "block-device-mapping.snapshot-id" {
+					return &ec2.DescribeImagesOutput{}, nil
+				}
+				assert.Equal(filter("tag:"+tagInstance, "i-001"), req.Filters[0])
+				return &ec2.DescribeImagesOutput{
+					Images: []*ec2.Image{
+						image("ami-001", "snap-001", 2*time.Hour),
+						image("ami-002", "snap-002", time.Hour),
+					},
+				}, nil
+			},
+			CreateImageFunc: func(req *ec2.CreateImageInput) (*ec2.CreateImageOutput, error) {
+				created = req
+				return &ec2.CreateImageOutput{ImageId: aws.String("ami-003")}, nil
+			},
+			DeregisterImageFunc: func(req *ec2.DeregisterImageInput) (*ec2.DeregisterImageOutput, error) {
+				deregistered = append(deregistered, *req.ImageId)
+				return &ec2.DeregisterImageOutput{}, nil
+			},
+			DescribeSnapshotsFunc: func(req *ec2.DescribeSnapshotsInput) (*ec2.DescribeSnapshotsOutput, error) {
+				assert.Equal([]*string{aws.String("snap-001")}, req.SnapshotIds)
+				return &ec2.DescribeSnapshotsOutput{
+					Snapshots: []*ec2.Snapshot{{SnapshotId: aws.String("snap-001")}},
+				}, nil
+			},
+			DeleteSnapshotFunc: func(req *ec2.DeleteSnapshotInput) (*ec2.DeleteSnapshotOutput, error) {
+				deleted = append(deleted, *req.SnapshotId)
+				return &ec2.DeleteSnapshotOutput{}, nil
+			},
+		},
+	})
+
+	results, err := e.Run()
+	assert.NoError(err)
+	assert.Equal(1, len(results))
+
+	res := results[0]
+	assert.NoError(res.Err)
+	assert.Equal("i-001", res.InstanceID)
+	assert.Equal("ami-003", res.CreatedImage)
+	assert.Equal([]string{"ami-001"}, res.DeregisteredImages)
+	assert.Equal([]string{"snap-001"}, res.DeletedSnapshots)
+	assert.Equal([]string{"ami-001"}, deregistered)
+	assert.Equal([]string{"snap-001"}, deleted)
+
+	assert.Equal("ebs-backup-db---i-001-86400", *created.Name)
+	assert.Equal("ebs-backup db-*: i-001", *created.Description)
+	assert.True(*created.NoReboot)
+	assert.Equal(2, len(created.TagSpecifications))
+	assert.Equal([]*ec2.Tag{
+		{Key: aws.String(tagInstance), Value: aws.String("i-001")},
+		{Key: aws.String(tagJob), Value: aws.String("db-*")},
+	}, created.TagSpecifications[0].Tags)
+	assert.Equal([]*ec2.Tag{
+		{Key: aws.String(tagImage), Value: aws.String("ebs-backup-db---i-001-86400")},
+		{Key: aws.String(tagInstance), Value: aws.String("i-001")},
+		{Key: aws.String(tagJob), Value: aws.String("db-*")},
+	}, created.TagSpecifications[1].Tags)
+}
+
+func TestImageSnapshotsExcluded(t *testing.T) {
+	assert := assert.New(t)
+	defer clock(time.Unix(86400*100, 0))()
+
+	var deleted []string
+
+	ami := &ec2.Tag{Key: aws.String(tagImage), Value: aws.String("ebs-backup-db-i-001-86400")}
+	set := []*ec2.Snapshot{
+		managedSnapshot("snap-001", "vol-gone", 48*time.Hour),
+		managedSnapshot("snap-002", "vol-gone", 24*time.Hour, ami),
+	}
+
+	e := New(Config{EC2: reapMock(&deleted, set...)})
+
+	snapshots, err := e.jobSnapshots()
+	assert.NoError(err)
+	assert.Equal([]Snapshot{snapshot(set[0])}, snapshots)
+	assert.Equal([]Snapshot{snapshot(set[0])}, managed([]Snapshot{snapshot(set[0]), snapshot(set[1])}))
+}
+
+func TestBackupImaged(t *testing.T) {
+	assert := assert.New(t)
+	defer clock(time.Unix(86400, 0))()
+
+	var deleted []string
+
+	e := New(Config{
+		Limit: 2,
+		EC2: mock{
+			DescribeSnapshotsFunc: func(req *ec2.DescribeSnapshotsInput) (*ec2.DescribeSnapshotsOutput, error) {
+				return &ec2.DescribeSnapshotsOutput{
+					Snapshots: []*ec2.Snapshot{
+						managedSnapshot("snap-001", "vol-xyz", 3*time.Hour),
+						managedSnapshot("snap-002", "vol-xyz", 2*time.Hour),
+						managedSnapshot("snap-003", "vol-xyz", time.Hour),
+					},
+				}, nil
+			},
+			DescribeImagesFunc: func(req *ec2.DescribeImagesInput) (*ec2.DescribeImagesOutput, error) {
+				assert.Equal("block-device-mapping.snapshot-id", *req.Filters[0].Name)
+				return &ec2.DescribeImagesOutput{
+					Images: []*ec2.Image{image("ami-001", "snap-001", 3*time.Hour)},
+				}, nil
+			},
+			CreateSnapshotFunc: func(*ec2.CreateSnapshotInput) (*ec2.Snapshot, error) {
+				return &ec2.Snapshot{SnapshotId: aws.String("snap-004"), StartTime: aws.Time(now())}, nil
+			},
+			DeleteSnapshotFunc: func(req *ec2.DeleteSnapshotInput) (*ec2.DeleteSnapshotOutput, error) {
+				deleted = append(deleted, *req.SnapshotId)
+				return &ec2.DeleteSnapshotOutput{}, nil
+			},
+		},
+	})
+
+	res := e.backup(Volume{ID: "vol-xyz"})
+
+	assert.NoError(res.Err)
+	assert.Equal([]string{"snap-002"}, deleted)
+	assert.Equal([]string{"snap-001"}, res.ProtectedSnapshots)
+}
+
+func TestBackupSkipsImageSnapshots(t *testing.T) {
+	assert := assert.New(t)
+	defer clock(time.Unix(86400, 0))()
+
+	ami := managedSnapshot("snap-002", "vol-xyz", 10*time.Minute,
+		&ec2.Tag{Key: aws.String(tagImage), Value: aws.String("ebs-backup-db-i-001-86400")})
+	ami.State = aws.String("pending")
+
+	e := New(Config{
+		Limit:    2,
+		Interval: time.Hour,
+		EC2: mock{
+			DescribeSnapshotsFunc: func(req *ec2.DescribeSnapshotsInput) (*ec2.DescribeSnapshotsOutput, error) {
+				return &ec2.DescribeSnapshotsOutput{
+					Snapshots: []*ec2.Snapshot{
+						managedSnapshot("snap-001", "vol-xyz", 2*time.Hour),
+						ami,
+					},
+				}, nil
+			},
+			CreateSnapshotFunc: func(*ec2.CreateSnapshotInput) (*ec2.Snapshot, error) {
+				return &ec2.Snapshot{SnapshotId: aws.String("snap-003"), StartTime: aws.Time(now())}, nil
+			},
+		},
+	})
+
+	res := e.backup(Volume{ID: "vol-xyz"})
+
+	assert.NoError(res.Err)
+	assert.Equal("", res.Skipped)
+	assert.Equal("", res.PendingSnapshot)
+	assert.Equal("snap-003", res.CreatedSnapshot)
+	assert.Empty(res.DeletedSnapshots)
+}
@@ -0,0 +1,47 @@
+package engine
+
+import (
+	"errors"
+
+	"github.com/apex/log"
+)
+
+// ErrLocked is returned by a Locker when the volume
+// is locked by another run.
+var ErrLocked = errors.New("volume is locked by another run")
+
+// Locker gives a single run exclusive access to a volume.
+//
+// Lock returns a func that releases the lock, or
+// `ErrLocked` if another run holds the lock.
+type Locker interface {
+	Lock(volumeID string) (unlock func() error, err error)
+}
+
+// Locked backs up `v` while holding its lock, if a `.Locker`
+// is configured. Volumes locked by another run are skipped.
+func (e *Engine) locked(v Volume) Result {
+	if e.Locker == nil {
+		return e.backup(v)
+	}
+
+	var res Result
+
+	unlock, err := e.Locker.Lock(v.ID)
+	if err == ErrLocked {
+		res.Skipped = err.Error()
+		return res
+	}
+	if err != nil {
+		res.Err = fail(PhaseLock, nil, err)
+		return res
+	}
+
+	defer func() {
+		if err := unlock(); err != nil {
+			log.WithError(err).WithField("volume_id", v.ID).Warn("unlock")
+		}
+	}()
+
+	return e.backup(v)
+}
@@ -0,0 +1,134 @@
+package engine
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/apex/log"
+)
+
+// pollInterval is the time between checks of a pending snapshot.
+const pollInterval = 15 * time.Second
+
+// waitMargin is the time left for the backup itself when the
+// wait for a pending snapshot is cut short by a lock or deadline.
+const waitMargin = time.Minute
+
+// sleep pauses the current goroutine, it is replaced in tests.
+var sleep = time.Sleep
+
+// Pending handles the oldest pending snapshot in `set`, if any.
+//
+// By default a pending snapshot blocks the backup and an error
+// is returned. When the snapshot is older than `.PendingMaxAge`
+// the backup proceeds anyway and the stale pending snapshots are
+// left out of the rotation, otherwise the method waits up to
+// `.PendingWait` for the snapshot to complete, see `pendingWait`.
+//
+// The method returns the snapshots of the volume, which are
+// refreshed if it waited, and records the pending snapshot,
+// its progress and age in `res`.
+func (e *Engine) pending(v Volume, set []Snapshot, res *Result) ([]Snapshot, error) {
+	s := oldestPending(set)
+	if s == nil {
+		return set, nil
+	}
+
+	res.PendingSnapshot = s.ID
+	res.PendingProgress = s.Progress
+	res.PendingAge = now().Sub(s.Created)
+
+	ctx := log.WithFields(log.Fields{
+		"volume_id": v.ID,
+		"snapshot":  res.PendingSnapshot,
+		"progress":  res.PendingProgress,
+		"age":       res.PendingAge,
+	})
+
+	if e.PendingMaxAge > 0 && res.PendingAge >= e.PendingMaxAge {
+		ctx.Warn("proceeding despite stale pending snapshot")
+		return e.fresh(set), nil
+	}
+
+	deadline := now().Add(e.pendingWait())
+
+	for now().Before(deadline) {
+		ctx.Info("waiting for pending snapshot")
+		sleep(pollInterval)
+
+		snapshots, err := e.snapshots(v.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		s = oldestPending(snapshots)
+		if s == nil {
+			return snapshots, nil
+		}
+
+		res.PendingSnapshot = s.ID
+		res.PendingProgress = s.Progress
+		res.PendingAge = now().Sub(s.Created)
+		set = snapshots
+	}
+
+	return nil, fail(PhasePending, ErrPending, fmt.Errorf("%s is %s complete and %s old",
+		res.PendingSnapshot, res.PendingProgress, res.PendingAge.Truncate(time.Second)))
+}
+
+// pendingWait returns how long `pending` may wait, `.PendingWait`
+// capped so that the backup completes a margin before the lock
+// expires and before `.Deadline`. Dry runs never wait.
+func (e *Engine) pendingWait() time.Duration {
+	if e.DryRun {
+		return 0
+	}
+
+	wait := e.PendingWait
+
+	if e.Locker != nil && e.LockTTL > 0 && e.LockTTL-waitMargin < wait {
+		wait = e.LockTTL - waitMargin
+	}
+
+	if !e.Deadline.IsZero() {
+		if left := e.Deadline.Sub(now()) - waitMargin; left < wait {
+			wait = left
+		}
+	}
+
+	return wait
+}
+
+// fresh returns the snapshots of `set` except the pending ones
+// older than `.PendingMaxAge`.
+func (e *Engine) fresh(set []Snapshot) []Snapshot {
+	var ret []Snapshot
+
+	for _, s := range set {
+		stale := strings.ToLower(s.State) == SnapshotPending && now().Sub(s.Created) >= e.PendingMaxAge
+		if !stale {
+			ret = append(ret, s)
+		}
+	}
+
+	return ret
+}
+
+// oldestPending returns the oldest pending snapshot in `set` or nil.
+func oldestPending(set []Snapshot) *Snapshot {
+	var ret *Snapshot
+
+	for i := range set {
+		s := &set[i]
+		if strings.ToLower(s.State) != SnapshotPending {
+			continue
+		}
+
+		if ret == nil || s.Created.Before(ret.Created) {
+			ret = s
+		}
+	}
+
+	return ret
+}
@@ -0,0 +1,221 @@
+package engine
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/stretchr/testify/assert"
+)
+
+// clock replaces `now` and `sleep` with a fake clock starting at `t`.
+func clock(t time.Time) func() {
+	now = func() time.Time { return t }
+	sleep = func(d time.Duration) { t = t.Add(d) }
+	return func() { now, sleep = time.Now, time.Sleep }
+}
+
+func pendingSnapshot(age time.Duration) *ec2.Snapshot {
+	return &ec2.Snapshot{
+		SnapshotId: aws.String("snap-001"),
+		StartTime:  aws.Time(now().Add(-age)),
+		State:      aws.String("pending"),
+		Progress:   aws.String("42%"),
+	}
+}
+
+func TestPendingErr(t *testing.T) {
+	assert := assert.New(t)
+	defer clock(time.Unix(3600, 0))()
+
+	e := New(Config{
+		EC2: mock{
+			DescribeSnapshotsFunc: func(req *ec2.DescribeSnapshotsInput) (*ec2.DescribeSnapshotsOutput, error) {
+				return &ec2.DescribeSnapshotsOutput{
+					Snapshots: []*ec2.Snapshot{pendingSnapshot(time.Hour)},
+				}, nil
+			},
+		},
+	})
+
+	res := e.backup(Volume{ID: "vol-xyz"})
+
+	assert.EqualError(res.Err, "volume has a snapshot in pending state: snap-001 is 42% complete and 1h0m0s old")
+	assert.True(errors.Is(res.Err, ErrPending))
+	assert.Equal(PhasePending, ErrorPhase(res.Err))
+	assert.True(Retryable(res.Err))
+	assert.Equal("snap-001", res.PendingSnapshot)
+	assert.Equal("42%", res.PendingProgress)
+	assert.Equal(time.Hour, res.PendingAge)
+}
+
+func TestPendingMaxAge(t *testing.T) {
+	assert := assert.New(t)
+	defer clock(time.Unix(86400, 0))()
+
+	e := New(Config{
+		Limit:         10,
+		PendingMaxAge: 6 * time.Hour,
+		EC2: mock{
+			DescribeSnapshotsFunc: func(req *ec2.DescribeSnapshotsInput) (*ec2.DescribeSnapshotsOutput, error) {
+				return &ec2.DescribeSnapshotsOutput{
+					Snapshots: []*ec2.Snapshot{pendingSnapshot(12 * time.Hour)},
+				}, nil
+			},
+			CreateSnapshotFunc: func(*ec2.CreateSnapshotInput) (*ec2.Snapshot, error) {
+				return &ec2.Snapshot{SnapshotId: aws.String("snap-002")}, nil
+			},
+		},
+	})
+
+	res := e.backup(Volume{ID: "vol-xyz"})
+
+	assert.NoError(res.Err)
+	assert.Equal("snap-002", res.CreatedSnapshot)
+	assert.Equal("snap-001", res.PendingSnapshot)
+	assert.Equal(12*time.Hour, res.PendingAge)
+}
+
+func TestPendingMaxAgeRotation(t *testing.T) {
+	assert := assert.New(t)
+	defer clock(time.Unix(86400, 0))()
+
+	var deleted []string
+
+	e := New(Config{
+		Limit:         2,
+		PendingMaxAge: 6 * time.Hour,
+		EC2: mock{
+			DescribeSnapshotsFunc: func(req *ec2.DescribeSnapshotsInput) (*ec2.DescribeSnapshotsOutput, error) {
+				return &ec2.DescribeSnapshotsOutput{
+					Snapshots: []*ec2.Snapshot{
+						pendingSnapshot(12 * time.Hour),
+						managedSnapshot("snap-010", "vol-xyz", 2*time.Hour),
+						managedSnapshot("snap-011", "vol-xyz", time.Hour),
+					},
+				}, nil
+			},
+			CreateSnapshotFunc: func(*ec2.CreateSnapshotInput) (*ec2.Snapshot, error) {
+				return &ec2.Snapshot{SnapshotId: aws.String("snap-002")}, nil
+			},
+			DeleteSnapshotFunc: func(req *ec2.DeleteSnapshotInput) (*ec2.DeleteSnapshotOutput, error) {
+				deleted = append(deleted, *req.SnapshotId)
+				return &ec2.DeleteSnapshotOutput{}, nil
+			},
+		},
+	})
+
+	res := e.backup(Volume{ID: "vol-xyz"})
+
+	assert.NoError(res.Err)
+	assert.Equal("snap-001", res.PendingSnapshot)
+	assert.Equal([]string{"snap-010"}, res.DeletedSnapshots)
+	assert.Equal([]string{"snap-010"}, deleted)
+}
+
+func TestPendingWait(t *testing.T) {
+	assert := assert.New(t)
+	defer clock(time.Unix(3600, 0))()
+
+	var calls int
+
+	e := New(Config{
+		Limit:       10,
+		PendingWait: time.Minute,
+		EC2: mock{
+			DescribeSnapshotsFunc: func(req *ec2.DescribeSnapshotsInput) (*ec2.DescribeSnapshotsOutput, error) {
+				calls++
+				s := pendingSnapshot(time.Minute)
+				if calls > 2 {
+					s.State = aws.String("completed")
+				}
+				return &ec2.DescribeSnapshotsOutput{
+					Snapshots: []*ec2.Snapshot{s},
+				}, nil
+			},
+			CreateSnapshotFunc: func(*ec2.CreateSnapshotInput) (*ec2.Snapshot, error) {
+				return &ec2.Snapshot{SnapshotId: aws.String("snap-002")}, nil
+			},
+		},
+	})
+
+	res := e.backup(Volume{ID: "vol-xyz"})
+
+	assert.NoError(res.Err)
+	assert.Equal(3, calls)
+	assert.Equal("snap-002", res.CreatedSnapshot)
+	assert.Equal("snap-001", res.PendingSnapshot)
+}
+
+func TestPendingWaitTimeout(t *testing.T) {
+	assert := assert.New(t)
+	defer clock(time.Unix(3600, 0))()
+
+	var calls int
+
+	e := New(Config{
+		PendingWait: time.Minute,
+		EC2: mock{
+			DescribeSnapshotsFunc: func(req *ec2.DescribeSnapshotsInput) (*ec2.DescribeSnapshotsOutput, error) {
+				calls++
+				return &ec2.DescribeSnapshotsOutput{
+					Snapshots: []*ec2.Snapshot{pendingSnapshot(time.Minute)},
+				}, nil
+			},
+		},
+	})
+
+	res := e.backup(Volume{ID: "vol-xyz"})
+
+	assert.Error(res.Err)
+	assert.Equal(5, calls)
+}
+
+func TestPendingWaitCapped(t *testing.T) {
+	assert := assert.New(t)
+	defer clock(time.Unix(3600, 0))()
+
+	e := New(Config{
+		PendingWait: time.Hour,
+		Locker:      locker(nil),
+		LockTTL:     15 * time.Minute,
+	})
+	assert.Equal(14*time.Minute, e.pendingWait())
+
+	e.Deadline = now().Add(10 * time.Minute)
+	assert.Equal(9*time.Minute, e.pendingWait())
+
+	e.Deadline = now().Add(30 * time.Second)
+	assert.True(e.pendingWait() < 0)
+
+	e.DryRun = true
+	e.Deadline = time.Time{}
+	assert.Equal(time.Duration(0), e.pendingWait())
+}
+
+func TestPendingDryRun(t *testing.T) {
+	assert := assert.New(t)
+	defer clock(time.Unix(3600, 0))()
+
+	var calls int
+
+	e := New(Config{
+		DryRun:      true,
+		PendingWait: time.Hour,
+		EC2: mock{
+			DescribeSnapshotsFunc: func(req *ec2.DescribeSnapshotsInput) (*ec2.DescribeSnapshotsOutput, error) {
+				calls++
+				return &ec2.DescribeSnapshotsOutput{
+					Snapshots: []*ec2.Snapshot{pendingSnapshot(time.Minute)},
+				}, nil
+			},
+		},
+	})
+
+	res := e.backup(Volume{ID: "vol-xyz"})
+
+	assert.Error(res.Err)
+	assert.Equal(1, calls)
+}
@@ -0,0 +1,98 @@
+package engine
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Tags that override the job configuration of a single volume.
+const (
+	tagRetain   = "ebs-backup:retain"
+	tagCopyTags = "ebs-backup:copy-tags"
+	tagSkip     = "ebs-backup:skip"
+	tagInterval = "ebs-backup:interval"
+	tagDRRegion = "ebs-backup:dr-region"
+	tagFastZone = "ebs-backup:fast-restore-zones"
+)
+
+// Policy is the backup policy of a single volume.
+type policy struct {
+	Limit     int
+	Interval  time.Duration
+	CopyTags  bool
+	Skip      bool
+	DRRegion  string
+	FastZones []string
+}
+
+// Policy returns the backup policy of `v`.
+//
+// The policy is the engine config overridden by the
+// `ebs-backup:*` tags of the volume, an error is returned
+// if one of the tags has an invalid value.
+func (e *Engine) policy(v Volume) (policy, error) {
+	p := policy{
+		Limit:     e.Limit,
+		Interval:  e.Interval,
+		CopyTags:  e.CopyTags,
+		FastZones: e.FastRestoreZones,
+	}
+
+	if value, ok := v.Tags[tagRetain]; ok {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			return p, invalid(tagRetain, value)
+		}
+		p.Limit = n
+	}
+
+	if value, ok := v.Tags[tagCopyTags]; ok {
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return p, invalid(tagCopyTags, value)
+		}
+		p.CopyTags = b
+	}
+
+	if value, ok := v.Tags[tagSkip]; ok {
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return p, invalid(tagSkip, value)
+		}
+		p.Skip = b
+	}
+
+	if value, ok := v.Tags[tagInterval]; ok {
+		d, err := time.ParseDuration(value)
+		if err != nil || d < 0 {
+			return p, invalid(tagInterval, value)
+		}
+		p.Interval = d
+	}
+
+	if value, ok := v.Tags[tagDRRegion]; ok {
+		if value == "" || value == e.Region {
+			return p, invalid(tagDRRegion, value)
+		}
+		p.DRRegion = value
+	}
+
+	if value, ok := v.Tags[tagFastZone]; ok {
+		zones := strings.FieldsFunc(value, func(r rune) bool {
+			return r == ',' || r == ' '
+		})
+		if len(zones) == 0 {
+			return p, invalid(tagFastZone, value)
+		}
+		p.FastZones = zones
+	}
+
+	return p, nil
+}
+
+// invalid returns an error for an invalid tag `value`.
+func invalid(key, value string) error {
+	return fmt.Errorf("invalid %s tag %q", key, value)
+}
@@ -0,0 +1,60 @@
+package engine
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPolicy(t *testing.T) {
+	assert := assert.New(t)
+
+	e := New(Config{
+		Limit:    5,
+		Interval: time.Hour,
+		CopyTags: true,
+		Region:   "us-east-1",
+	})
+
+	p, err := e.policy(Volume{})
+	assert.NoError(err)
+	assert.Equal(policy{Limit: 5, Interval: time.Hour, CopyTags: true}, p)
+
+	p, err = e.policy(Volume{
+		Tags: map[string]string{
+			"ebs-backup:retain":    "14",
+			"ebs-backup:copy-tags": "false",
+			"ebs-backup:skip":      "true",
+			"ebs-backup:interval":  "6h",
+			"ebs-backup:dr-region": "us-west-2",
+		},
+	})
+	assert.NoError(err)
+	assert.Equal(policy{
+		Limit:    14,
+		Interval: 6 * time.Hour,
+		CopyTags: false,
+		Skip:     true,
+		DRRegion: "us-west-2",
+	}, p)
+}
+
+func TestPolicyInvalid(t *testing.T) {
+	assert := assert.New(t)
+
+	e := New(Config{Limit: 5, Region: "us-east-1"})
+
+	tags := map[string]string{
+		"ebs-backup:retain":    "0",
+		"ebs-backup:copy-tags": "maybe",
+		"ebs-backup:skip":      "yes please",
+		"ebs-backup:interval":  "-1h",
+		"ebs-backup:dr-region": "us-east-1",
+	}
+
+	for k, v := range tags {
+		_, err := e.policy(Volume{Tags: map[string]string{k: v}})
+		assert.Error(err, k)
+	}
+}
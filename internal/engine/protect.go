@@ -0,0 +1,128 @@
+package engine
+
+import (
+	"fmt"
+
+	"github.com/apex/log"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+)
+
+// errInUse is the error code of deleting a snapshot in use by an AMI.
+const errInUse = "InvalidSnapshot.InUse"
+
+// references returns the snapshots of `ids` that back a registered
+// AMI, or are referenced by the latest or default version of a launch
+// template, keyed by id with the reason they are in use.
+func references(api ec2iface.EC2API, ids []string) (map[string]string, error) {
+	ret := make(map[string]string)
+
+	if len(ids) == 0 {
+		return ret, nil
+	}
+
+	images, err := api.DescribeImages(&ec2.DescribeImagesInput{
+		Owners:  aws.StringSlice([]string{"self"}),
+		Filters: []*ec2.Filter{filter("block-device-mapping.snapshot-id", ids...)},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, img := range images.Images {
+		if aws.StringValue(img.State) == ec2.ImageStateDeregistered {
+			continue
+		}
+
+		for _, b := range img.BlockDeviceMappings {
+			if b.Ebs != nil && b.Ebs.SnapshotId != nil {
+				ret[*b.Ebs.SnapshotId] = "backs " + aws.StringValue(img.ImageId)
+			}
+		}
+	}
+
+	var token *string
+
+	for {
+		resp, err := api.DescribeLaunchTemplateVersions(&ec2.DescribeLaunchTemplateVersionsInput{
+			Versions:  aws.StringSlice([]string{"$Latest", "$Default"}),
+			NextToken: token,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, v := range resp.LaunchTemplateVersions {
+			if v.LaunchTemplateData == nil {
+				continue
+			}
+
+			for _, b := range v.LaunchTemplateData.BlockDeviceMappings {
+				if b.Ebs == nil || b.Ebs.SnapshotId == nil {
+					continue
+				}
+
+				if _, ok := ret[*b.Ebs.SnapshotId]; !ok {
+					ret[*b.Ebs.SnapshotId] = fmt.Sprintf("referenced by launch template %s version %d",
+						aws.StringValue(v.LaunchTemplateId), aws.Int64Value(v.VersionNumber))
+				}
+			}
+		}
+
+		if resp.NextToken == nil {
+			return ret, nil
+		}
+		token = resp.NextToken
+	}
+}
+
+// protect splits `set` into the snapshots that may be deleted
+// and those in use, e.g. by an AMI or a launch template, when
+// `p` is a Protector.
+func (e *Engine) protect(p Provider, set []Snapshot) (free, protected []Snapshot, err error) {
+	refs, err := e.used(p, set)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, s := range set {
+		if _, ok := refs[s.ID]; ok {
+			protected = append(protected, s)
+			continue
+		}
+
+		free = append(free, s)
+	}
+
+	return free, protected, nil
+}
+
+// used returns the snapshots of `set` in use keyed by id with the
+// reason they are in use, if `p` is a Protector.
+func (e *Engine) used(p Provider, set []Snapshot) (map[string]string, error) {
+	if p, ok := p.(Protector); ok && len(set) > 0 {
+		return p.InUse(ids(set))
+	}
+
+	return map[string]string{}, nil
+}
+
+// inUse returns true if `err` is the error of deleting a snapshot
+// in use, e.g. by an AMI registered since `references` was called.
+func inUse(err error) bool {
+	if err, ok := err.(awserr.Error); ok {
+		return err.Code() == errInUse
+	}
+
+	return false
+}
+
+// skip logs that snapshot `id` is not deleted because of `reason`.
+func skip(id, reason string) {
+	log.WithFields(log.Fields{
+		"snapshot_id": id,
+		"reason":      reason,
+	}).Warn("snapshot not deleted")
+}
@@ -0,0 +1,83 @@
+package engine
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/stretchr/testify/assert"
+)
+
+func launchTemplate(id string, version int64, snapshot string) *ec2.LaunchTemplateVersion {
+	return &ec2.LaunchTemplateVersion{
+		LaunchTemplateId: aws.String(id),
+		VersionNumber:    aws.Int64(version),
+		LaunchTemplateData: &ec2.ResponseLaunchTemplateData{
+			BlockDeviceMappings: []*ec2.LaunchTemplateBlockDeviceMapping{
+				{Ebs: &ec2.LaunchTemplateEbsBlockDevice{SnapshotId: aws.String(snapshot)}},
+			},
+		},
+	}
+}
+
+func TestReferences(t *testing.T) {
+	assert := assert.New(t)
+
+	e := New(Config{
+		EC2: mock{
+			DescribeImagesFunc: func(*ec2.DescribeImagesInput) (*ec2.DescribeImagesOutput, error) {
+				return &ec2.DescribeImagesOutput{
+					Images: []*ec2.Image{image("ami-001", "snap-001", time.Hour)},
+				}, nil
+			},
+			DescribeLaunchTemplateVersionsFunc: func(req *ec2.DescribeLaunchTemplateVersionsInput) (*ec2.DescribeLaunchTemplateVersionsOutput, error) {
+				if req.NextToken == nil {
+					return &ec2.DescribeLaunchTemplateVersionsOutput{
+						LaunchTemplateVersions: []*ec2.LaunchTemplateVersion{launchTemplate("lt-001", 3, "snap-002")},
+						NextToken:              aws.String("next"),
+					}, nil
+				}
+				return &ec2.DescribeLaunchTemplateVersionsOutput{
+					LaunchTemplateVersions: []*ec2.LaunchTemplateVersion{launchTemplate("lt-002", 1, "snap-001")},
+				}, nil
+			},
+		},
+	})
+
+	refs, err := references(e.EC2, []string{"snap-001", "snap-002", "snap-003"})
+	assert.NoError(err)
+	assert.Equal(map[string]string{
+		"snap-001": "backs ami-001",
+		"snap-002": "referenced by launch template lt-001 version 3",
+	}, refs)
+}
+
+func TestDeleteProtected(t *testing.T) {
+	assert := assert.New(t)
+
+	var deleted []string
+
+	e := New(Config{
+		EC2: mock{
+			DescribeLaunchTemplateVersionsFunc: func(*ec2.DescribeLaunchTemplateVersionsInput) (*ec2.DescribeLaunchTemplateVersionsOutput, error) {
+				return &ec2.DescribeLaunchTemplateVersionsOutput{
+					LaunchTemplateVersions: []*ec2.LaunchTemplateVersion{launchTemplate("lt-001", 1, "snap-001")},
+				}, nil
+			},
+			DeleteSnapshotFunc: func(req *ec2.DeleteSnapshotInput) (*ec2.DeleteSnapshotOutput, error) {
+				if *req.SnapshotId == "snap-002" {
+					return nil, awserr.New(errInUse, "snapshot is in use by ami-002", nil)
+				}
+				deleted = append(deleted, *req.SnapshotId)
+				return &ec2.DeleteSnapshotOutput{}, nil
+			},
+		},
+	})
+
+	ids, err := e.delete(e.ec2Provider(), []Snapshot{{ID: "snap-001"}, {ID: "snap-002"}, {ID: "snap-003"}})
+	assert.NoError(err)
+	assert.Equal([]string{"snap-003"}, ids)
+	assert.Equal([]string{"snap-003"}, deleted)
+}
@@ -0,0 +1,142 @@
+package engine
+
+import (
+	"errors"
+	"time"
+)
+
+// Snapshot states of a provider, the states of EBS snapshots.
+const (
+	SnapshotPending   = "pending"
+	SnapshotCompleted = "completed"
+	SnapshotError     = "error"
+)
+
+// Tag set on a snapshot once it was copied to the DR region by
+// a provider that is not a `Replicator`, its value is the id of
+// the copy.
+const tagCopied = "ebs-backup:copied"
+
+// ErrNotSupported is returned by a Provider that does
+// not support an operation, e.g. copying snapshots.
+var ErrNotSupported = errors.New("not supported by the provider")
+
+// Volume is a volume of a Provider.
+//
+// Name, InstanceID and Device are used in description and tag
+// templates, Tags are the labels of the volume and configure
+// its policy like the tags of an EBS volume.
+type Volume struct {
+	ID         string
+	Name       string
+	InstanceID string
+	Device     string
+	Tags       map[string]string
+}
+
+// Snapshot is a snapshot of a Provider.
+//
+// Archived is true if the snapshot is in the archive tier of an
+// `Archiver`, or temporarily restored from it.
+type Snapshot struct {
+	ID       string
+	VolumeID string
+	State    string
+	Progress string
+	Created  time.Time
+	Archived bool
+	Tags     map[string]string
+}
+
+// Selector selects the volumes to back up, its fields are
+// the configured `.Name`, `.Devices` and `.VolumeIDs`, each
+// provider interprets them for its volumes. VolumeIDs, if
+// any, replace the name and devices.
+type Selector struct {
+	Name      string
+	Devices   []string
+	VolumeIDs []string
+}
+
+// Provider is a block storage backend.
+//
+// The engine backs up the volumes of a provider with the same
+// policy, retention, locking and reporting, `EC2Provider` is the
+// provider of EBS volumes and the default. Features specific to
+// a backend are optional interfaces of the provider, see
+// `Protector`, `Archiver`, `FastRestorer` and `Replicator`.
+type Provider interface {
+	// Volumes returns the volumes matched by `s`.
+	Volumes(s Selector) ([]Volume, error)
+
+	// Snapshots returns the snapshots of volume `id`.
+	Snapshots(id string) ([]Snapshot, error)
+
+	// CreateSnapshot creates a snapshot of `v`.
+	CreateSnapshot(v Volume, description string, tags map[string]string) (Snapshot, error)
+
+	// TagSnapshot sets `tags` on snapshot `id`.
+	TagSnapshot(id string, tags map[string]string) error
+
+	// DeleteSnapshot deletes snapshot `id`.
+	DeleteSnapshot(id string) error
+
+	// CopySnapshot copies `s` to `region`, or returns `ErrNotSupported`.
+	CopySnapshot(s Snapshot, region string, tags map[string]string) (Snapshot, error)
+}
+
+// Protector is a Provider whose snapshots can be in use by other
+// resources, e.g. AMIs, the engine never deletes those snapshots.
+type Protector interface {
+	// InUse returns the snapshots of `ids` that are in use,
+	// keyed by id with the reason they are in use.
+	InUse(ids []string) (map[string]string, error)
+}
+
+// Archiver is a Provider with an archive tier, see `tiering`.
+type Archiver interface {
+	// ArchiveSnapshot moves snapshot `id` to the archive tier.
+	ArchiveSnapshot(id string) error
+}
+
+// FastRestorer is a Provider whose snapshots can be restored
+// without latency in some zones, see `fastRestore`.
+type FastRestorer interface {
+	// EnableFastRestore enables fast restores of snapshot `id` in `zones`.
+	EnableFastRestore(id string, zones []string) error
+
+	// DisableFastRestore disables fast restores of snapshot `id` in `zones`.
+	DisableFastRestore(id string, zones []string) error
+
+	// UntagSnapshot removes the tags `keys` from snapshot `id`.
+	UntagSnapshot(id string, keys ...string) error
+}
+
+// Replicator is a Provider that lists the copies of the snapshots
+// of a volume in a DR region, the copies are then rotated like the
+// snapshots of the volume, see `replicate`.
+type Replicator interface {
+	// Copies returns the snapshots copied from volume `id` to `region`.
+	Copies(id, region string) ([]Snapshot, error)
+
+	// Regional returns the provider of `region`.
+	Regional(region string) Provider
+}
+
+// provider returns `.Provider`, or the EC2Provider of `.EC2`.
+func (e *Engine) provider() Provider {
+	if e.Provider != nil {
+		return e.Provider
+	}
+
+	return e.ec2Provider()
+}
+
+// ec2Provider returns the EC2Provider of `.EC2`.
+func (e *Engine) ec2Provider() EC2Provider {
+	return EC2Provider{
+		EC2:       e.EC2,
+		EC2Region: e.EC2Region,
+		Region:    e.Region,
+	}
+}
@@ -0,0 +1,264 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// memory is an in-memory Provider.
+type memory struct {
+	volumes   []Volume
+	snapshots map[string]Snapshot
+	described map[string]string
+	deleted   []string
+	failing   map[string]bool
+	copies    []string
+	next      int
+}
+
+func (m *memory) Volumes(Selector) ([]Volume, error) {
+	return m.volumes, nil
+}
+
+func (m *memory) Snapshots(id string) ([]Snapshot, error) {
+	var ret []Snapshot
+	for _, s := range m.snapshots {
+		if s.VolumeID == id {
+			ret = append(ret, s)
+		}
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i].ID < ret[j].ID })
+	return ret, nil
+}
+
+func (m *memory) CreateSnapshot(v Volume, description string, tags map[string]string) (Snapshot, error) {
+	m.next++
+	s := Snapshot{
+		ID:       fmt.Sprintf("new-%03d", m.next),
+		VolumeID: v.ID,
+		State:    SnapshotPending,
+		Created:  now(),
+		Tags:     tags,
+	}
+	m.snapshots[s.ID] = s
+	if m.described != nil {
+		m.described[s.ID] = description
+	}
+	return s, nil
+}
+
+func (m *memory) TagSnapshot(id string, tags map[string]string) error {
+	s := m.snapshots[id]
+	if s.Tags == nil {
+		s.Tags = make(map[string]string)
+	}
+	for k, v := range tags {
+		s.Tags[k] = v
+	}
+	m.snapshots[id] = s
+	return nil
+}
+
+func (m *memory) DeleteSnapshot(id string) error {
+	if m.failing[id] {
+		return errors.New("boom")
+	}
+	delete(m.snapshots, id)
+	m.deleted = append(m.deleted, id)
+	return nil
+}
+
+func (m *memory) CopySnapshot(s Snapshot, region string, tags map[string]string) (Snapshot, error) {
+	m.copies = append(m.copies, s.ID+"@"+region)
+	return Snapshot{ID: "copy-" + s.ID, Tags: tags}, nil
+}
+
+// stored returns an in-memory snapshot of `volume` created `age` ago.
+func stored(id, volume string, age time.Duration, tags map[string]string) Snapshot {
+	return Snapshot{
+		ID:       id,
+		VolumeID: volume,
+		State:    SnapshotCompleted,
+		Created:  now().Add(-age),
+		Tags:     tags,
+	}
+}
+
+func TestProviderRun(t *testing.T) {
+	assert := assert.New(t)
+	defer clock(time.Unix(86400, 0))()
+
+	m := &memory{
+		volumes: []Volume{{ID: "vol-001", Name: "db-1", Tags: map[string]string{"team": "data"}}},
+		snapshots: map[string]Snapshot{
+			"snap-001": stored("snap-001", "vol-001", 3*time.Hour, nil),
+			"snap-002": stored("snap-002", "vol-001", 2*time.Hour, map[string]string{tagHold: "INC-1"}),
+			"snap-003": stored("snap-003", "vol-001", time.Hour, nil),
+			"snap-004": stored("snap-004", "vol-002", 5*time.Hour, nil),
+		},
+		described: map[string]string{},
+	}
+
+	e := New(Config{
+		Name:        "db-*",
+		Limit:       2,
+		CopyTags:    true,
+		Description: "backup of {{.VolumeID}}",
+		Provider:    m,
+	})
+
+	results, err := e.Run()
+	assert.NoError(err)
+	assert.Equal(1, len(results))
+
+	res := results[0]
+	assert.NoError(res.Err)
+	assert.Equal("vol-001", res.VolumeID)
+	assert.Equal("new-001", res.CreatedSnapshot)
+	assert.Equal([]string{"snap-001"}, res.DeletedSnapshots)
+	assert.Equal([]string{"snap-002"}, res.HeldSnapshots)
+	assert.Equal([]string{"snap-001"}, m.deleted)
+	assert.Contains(m.snapshots, "snap-004")
+
+	created := m.snapshots["new-001"]
+	assert.Equal("backup of vol-001", m.described["new-001"])
+	assert.Equal("data", created.Tags["team"])
+	assert.Equal("db-*", created.Tags[tagJob])
+}
+
+func TestProviderPolicy(t *testing.T) {
+	assert := assert.New(t)
+	defer clock(time.Unix(86400, 0))()
+
+	m := &memory{
+		snapshots: map[string]Snapshot{
+			"snap-001": stored("snap-001", "vol-001", time.Hour, nil),
+		},
+	}
+
+	e := New(Config{Limit: 2, Interval: 2 * time.Hour, Provider: m})
+
+	res := e.backup(Volume{ID: "vol-001"})
+	assert.NoError(res.Err)
+	assert.Equal("snapshot snap-001 is 1h0m0s old, interval is 2h0m0s", res.Skipped)
+
+	res = e.backup(Volume{ID: "vol-001", Tags: map[string]string{tagSkip: "true"}})
+	assert.Equal("volume is tagged "+tagSkip, res.Skipped)
+
+	res = e.backup(Volume{ID: "vol-001", Tags: map[string]string{tagRetain: "x"}})
+	assert.Equal(PhasePolicy, ErrorPhase(res.Err))
+}
+
+func TestProviderPending(t *testing.T) {
+	assert := assert.New(t)
+	defer clock(time.Unix(86400, 0))()
+
+	pending := stored("snap-001", "vol-001", time.Hour, nil)
+	pending.State = SnapshotPending
+	pending.Progress = "42%"
+
+	m := &memory{snapshots: map[string]Snapshot{"snap-001": pending}}
+	e := New(Config{Limit: 2, Provider: m})
+
+	res := e.backup(Volume{ID: "vol-001"})
+	assert.True(errors.Is(res.Err, ErrPending))
+	assert.Equal("snap-001", res.PendingSnapshot)
+	assert.Equal("", res.CreatedSnapshot)
+}
+
+func TestProviderDryRun(t *testing.T) {
+	assert := assert.New(t)
+	defer clock(time.Unix(86400, 0))()
+
+	m := &memory{
+		snapshots: map[string]Snapshot{
+			"snap-001": stored("snap-001", "vol-001", 2*time.Hour, nil),
+			"snap-002": stored("snap-002", "vol-001", time.Hour, nil),
+		},
+	}
+
+	e := New(Config{Limit: 2, DryRun: true, Provider: m})
+
+	res := e.backup(Volume{ID: "vol-001"})
+	assert.NoError(res.Err)
+	assert.Equal([]string{"snap-001"}, res.DeletedSnapshots)
+	assert.Equal("", res.CreatedSnapshot)
+	assert.Empty(m.deleted)
+}
+
+func TestProviderDeleteErrors(t *testing.T) {
+	assert := assert.New(t)
+	defer clock(time.Unix(86400, 0))()
+
+	m := &memory{
+		snapshots: map[string]Snapshot{
+			"snap-001": stored("snap-001", "vol-001", 3*time.Hour, nil),
+			"snap-002": stored("snap-002", "vol-001", 2*time.Hour, nil),
+		},
+		failing: map[string]bool{"snap-001": true},
+	}
+
+	e := New(Config{Limit: 1, Provider: m})
+
+	res := e.backup(Volume{ID: "vol-001"})
+	assert.True(errors.Is(res.Err, ErrPrune))
+	assert.Equal([]string{"snap-002"}, res.DeletedSnapshots)
+	assert.Equal(map[string]string{"snap-001": "boom"}, FailedDeletions(res.Err))
+}
+
+func TestProviderReplicate(t *testing.T) {
+	assert := assert.New(t)
+	defer clock(time.Unix(86400, 0))()
+
+	m := &memory{
+		snapshots: map[string]Snapshot{
+			"snap-001": stored("snap-001", "vol-001", time.Hour, nil),
+		},
+	}
+
+	e := New(Config{Limit: 3, Region: "us-west-2", Provider: m})
+	v := Volume{ID: "vol-001", Tags: map[string]string{tagDRRegion: "us-east-1"}}
+
+	res := e.backup(v)
+	assert.NoError(res.Err)
+	assert.Equal("us-east-1", res.DRRegion)
+	assert.Equal("copy-snap-001", res.CopiedSnapshot)
+	assert.Equal("copy-snap-001", m.snapshots["snap-001"].Tags[tagCopied])
+
+	id, _, err := e.replicate(v, policy{DRRegion: "us-east-1"}, []Snapshot{m.snapshots["snap-001"]})
+	assert.NoError(err)
+	assert.Equal("", id)
+	assert.Equal([]string{"snap-001@us-east-1"}, m.copies)
+}
+
+func TestProviderCapabilities(t *testing.T) {
+	assert := assert.New(t)
+	defer clock(time.Unix(86400*100, 0))()
+
+	m := &memory{
+		snapshots: map[string]Snapshot{
+			"snap-001": stored("snap-001", "vol-001", 60*24*time.Hour, nil),
+		},
+	}
+
+	e := New(Config{Limit: 2, ArchiveAfter: 30 * 24 * time.Hour, Provider: m})
+
+	res := e.backup(Volume{ID: "vol-001"})
+	assert.NoError(res.Err)
+	assert.Empty(res.ArchivedSnapshots)
+
+	m.snapshots = map[string]Snapshot{
+		"snap-001": stored("snap-001", "vol-001", time.Hour, nil),
+	}
+	e.FastRestoreZones = []string{"us-east-1a"}
+
+	res = e.backup(Volume{ID: "vol-001"})
+	assert.NoError(res.Err)
+	assert.NotEmpty(res.CreatedSnapshot)
+	assert.Empty(res.FastRestoreSnapshot)
+}
@@ -0,0 +1,189 @@
+package engine
+
+import (
+	"sort"
+
+	"github.com/apex/log"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+// Reap deletes the managed snapshots of volumes that no longer exist.
+//
+// Snapshots are managed when they carry the `ebs-backup:job` tag,
+// when `.Job` is set only the snapshots of that job are considered.
+// Copies in a DR region, pending snapshots, snapshots
+// on hold and snapshots backing an AMI are ignored.
+//
+// The orphaned snapshots of a volume are rotated with the orphan
+// retention policy, the newest `.OrphanKeep` snapshots are kept
+// until they are older than `.OrphanMaxAge`, or forever when it
+// is zero. Archived snapshots are not deleted before they spent
+// `MinArchiveDuration` in the archive tier. The method returns a
+// result per orphaned volume.
+func (e *Engine) Reap() ([]Result, error) {
+	set, err := e.jobSnapshots()
+	if err != nil {
+		return nil, err
+	}
+
+	byVolume := make(map[string][]Snapshot)
+
+	for _, s := range set {
+		if _, ok := s.Tags[tagSourceSnapshot]; ok {
+			continue
+		}
+
+		if s.State == SnapshotPending {
+			continue
+		}
+
+		if _, ok := s.Tags[tagHold]; ok {
+			continue
+		}
+
+		byVolume[s.VolumeID] = append(byVolume[s.VolumeID], s)
+	}
+
+	orphans, err := e.orphans(byVolume)
+	if err != nil {
+		return nil, err
+	}
+
+	log.WithField("volumes", len(orphans)).Info("reap")
+
+	results := make([]Result, 0, len(orphans))
+
+	for _, id := range orphans {
+		res := e.reap(byVolume[id])
+		res.VolumeID = id
+
+		ctx := log.WithField("volume_id", id)
+		if res.Err != nil {
+			ctx.WithError(res.Err).Error("reap")
+		} else {
+			ctx.WithField("deleted", res.DeletedSnapshots).Info("reap")
+		}
+
+		results = append(results, res)
+	}
+
+	return results, nil
+}
+
+// maxFilterValues is the maximum number of values of an EC2 filter.
+const maxFilterValues = 200
+
+// jobSnapshots returns the managed snapshots of the account, or
+// those of `.Job` when it is set, AMI snapshots are excluded.
+func (e *Engine) jobSnapshots() ([]Snapshot, error) {
+	var ret []Snapshot
+
+	f := filter("tag-key", tagJob)
+	if e.Job != "" {
+		f = filter("tag:"+tagJob, e.Job)
+	}
+
+	err := e.EC2.DescribeSnapshotsPages(&ec2.DescribeSnapshotsInput{
+		OwnerIds: aws.StringSlice([]string{"self"}),
+		Filters:  []*ec2.Filter{f},
+	}, func(page *ec2.DescribeSnapshotsOutput, last bool) bool {
+		for _, s := range page.Snapshots {
+			if snap := snapshot(s); !fromImage(snap) {
+				ret = append(ret, snap)
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+// orphans returns the sorted ids of the volumes of
+// `byVolume` that no longer exist.
+//
+// Volumes are described by batches of `maxFilterValues` ids.
+func (e *Engine) orphans(byVolume map[string][]Snapshot) ([]string, error) {
+	if len(byVolume) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]string, 0, len(byVolume))
+	for id := range byVolume {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	exists := make(map[string]bool, len(ids))
+
+	for i := 0; i < len(ids); i += maxFilterValues {
+		batch := ids[i:]
+		if len(batch) > maxFilterValues {
+			batch = batch[:maxFilterValues]
+		}
+
+		err := e.EC2.DescribeVolumesPages(&ec2.DescribeVolumesInput{
+			Filters: []*ec2.Filter{filter("volume-id", batch...)},
+		}, func(page *ec2.DescribeVolumesOutput, last bool) bool {
+			for _, v := range page.Volumes {
+				exists[aws.StringValue(v.VolumeId)] = true
+			}
+			return true
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var ret []string
+
+	for _, id := range ids {
+		if !exists[id] {
+			ret = append(ret, id)
+		}
+	}
+
+	return ret, nil
+}
+
+// reap rotates the orphaned snapshots `set` of a volume.
+func (e *Engine) reap(set []Snapshot) Result {
+	var res Result
+	var expired []Snapshot
+
+	set, _, err := e.protect(e.ec2Provider(), set)
+	if err != nil {
+		res.Err = fail(PhaseDescribe, nil, err)
+		return res
+	}
+
+	sorted := byTime(set)
+	sort.Sort(sorted)
+
+	for i, s := range sorted {
+		keep := i < e.OrphanKeep
+		if keep && e.OrphanMaxAge > 0 {
+			keep = now().Sub(s.Created) < e.OrphanMaxAge
+		}
+
+		if !keep && archived(s) && !e.archivable(s) {
+			continue
+		}
+
+		if !keep {
+			expired = append(expired, s)
+		}
+	}
+
+	if e.DryRun {
+		res.DeletedSnapshots = ids(expired)
+		return res
+	}
+
+	res.DeletedSnapshots, err = e.delete(e.ec2Provider(), expired)
+	res.Err = fail(PhasePrune, ErrPrune, err)
+	return res
+}
@@ -0,0 +1,151 @@
+package engine
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/stretchr/testify/assert"
+)
+
+// managedSnapshot returns a completed managed snapshot of `volume`.
+func managedSnapshot(id, volume string, age time.Duration, tags ...*ec2.Tag) *ec2.Snapshot {
+	return &ec2.Snapshot{
+		SnapshotId: aws.String(id),
+		VolumeId:   aws.String(volume),
+		StartTime:  aws.Time(now().Add(-age)),
+		State:      aws.String("completed"),
+		Tags:       append(tags, &ec2.Tag{Key: aws.String(tagJob), Value: aws.String("db")}),
+	}
+}
+
+func reapMock(deleted *[]string, set ...*ec2.Snapshot) mock {
+	return mock{
+		DescribeSnapshotsFunc: func(req *ec2.DescribeSnapshotsInput) (*ec2.DescribeSnapshotsOutput, error) {
+			return &ec2.DescribeSnapshotsOutput{Snapshots: set}, nil
+		},
+		DescribeVolumesFunc: func(req *ec2.DescribeVolumesInput) (*ec2.DescribeVolumesOutput, error) {
+			return &ec2.DescribeVolumesOutput{
+				Volumes: []*ec2.Volume{{VolumeId: aws.String("vol-live")}},
+			}, nil
+		},
+		DeleteSnapshotFunc: func(req *ec2.DeleteSnapshotInput) (*ec2.DeleteSnapshotOutput, error) {
+			*deleted = append(*deleted, *req.SnapshotId)
+			return &ec2.DeleteSnapshotOutput{}, nil
+		},
+	}
+}
+
+func TestReap(t *testing.T) {
+	assert := assert.New(t)
+	defer clock(time.Unix(86400*100, 0))()
+
+	var deleted []string
+	var filters []*ec2.Filter
+
+	m := reapMock(&deleted,
+		managedSnapshot("snap-001", "vol-live", 72*time.Hour),
+		managedSnapshot("snap-002", "vol-gone", 72*time.Hour),
+		managedSnapshot("snap-003", "vol-gone", 48*time.Hour),
+		managedSnapshot("snap-004", "vol-gone", 24*time.Hour),
+		managedSnapshot("snap-005", "vol-gone", time.Hour,
+			&ec2.Tag{Key: aws.String(tagSourceSnapshot), Value: aws.String("snap-000")}),
+	)
+	describe := m.DescribeSnapshotsFunc
+	m.DescribeSnapshotsFunc = func(req *ec2.DescribeSnapshotsInput) (*ec2.DescribeSnapshotsOutput, error) {
+		filters = req.Filters
+		return describe(req)
+	}
+
+	e := New(Config{
+		EC2:        m,
+		Job:        "db",
+		OrphanKeep: 1,
+	})
+
+	results, err := e.Reap()
+	assert.NoError(err)
+	assert.Equal([]*ec2.Filter{filter("tag:"+tagJob, "db")}, filters)
+	assert.Equal([]Result{{
+		VolumeID:         "vol-gone",
+		DeletedSnapshots: []string{"snap-003", "snap-002"},
+	}}, results)
+	assert.Equal([]string{"snap-003", "snap-002"}, deleted)
+}
+
+func TestReapMaxAge(t *testing.T) {
+	assert := assert.New(t)
+	defer clock(time.Unix(86400*100, 0))()
+
+	var deleted []string
+
+	e := New(Config{
+		EC2: reapMock(&deleted,
+			managedSnapshot("snap-001", "vol-gone", 91*24*time.Hour),
+			managedSnapshot("snap-002", "vol-other", 24*time.Hour),
+		),
+		OrphanKeep:   1,
+		OrphanMaxAge: 90 * 24 * time.Hour,
+	})
+
+	results, err := e.Reap()
+	assert.NoError(err)
+	assert.Equal(2, len(results))
+	assert.Equal("vol-gone", results[0].VolumeID)
+	assert.Equal([]string{"snap-001"}, results[0].DeletedSnapshots)
+	assert.Equal("vol-other", results[1].VolumeID)
+	assert.Empty(results[1].DeletedSnapshots)
+	assert.Equal([]string{"snap-001"}, deleted)
+}
+
+func TestReapDryRun(t *testing.T) {
+	assert := assert.New(t)
+	defer clock(time.Unix(86400*100, 0))()
+
+	var deleted []string
+
+	e := New(Config{
+		EC2: reapMock(&deleted,
+			managedSnapshot("snap-001", "vol-gone", 48*time.Hour),
+			managedSnapshot("snap-002", "vol-gone", 24*time.Hour),
+		),
+		DryRun: true,
+	})
+
+	results, err := e.Reap()
+	assert.NoError(err)
+	assert.Equal([]string{"snap-002", "snap-001"}, results[0].DeletedSnapshots)
+	assert.Empty(deleted)
+}
+
+func TestReapBatches(t *testing.T) {
+	assert := assert.New(t)
+	defer clock(time.Unix(86400*100, 0))()
+
+	var deleted []string
+	var batches []int
+	var set []*ec2.Snapshot
+
+	for i := 0; i < 450; i++ {
+		set = append(set, managedSnapshot(fmt.Sprintf("snap-%03d", i), fmt.Sprintf("vol-%03d", i), time.Hour))
+	}
+
+	m := reapMock(&deleted, set...)
+	describe := m.DescribeVolumesFunc
+	m.DescribeVolumesFunc = func(req *ec2.DescribeVolumesInput) (*ec2.DescribeVolumesOutput, error) {
+		batches = append(batches, len(req.Filters[0].Values))
+		return describe(req)
+	}
+
+	e := New(Config{
+		EC2:    m,
+		DryRun: true,
+	})
+
+	results, err := e.Reap()
+	assert.NoError(err)
+	assert.Equal([]int{200, 200, 50}, batches)
+	assert.Equal(450, len(results))
+}
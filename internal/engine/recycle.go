@@ -0,0 +1,226 @@
+package engine
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/recyclebin"
+)
+
+// RecycleRule is a Recycle Bin retention rule that retains
+// the managed snapshots once deleted.
+type RecycleRule struct {
+	ID        string
+	Retention time.Duration
+}
+
+// Recycled is a snapshot in the Recycle Bin.
+type Recycled struct {
+	SnapshotID  string
+	VolumeID    string
+	Description string
+	Deleted     time.Time
+	Expires     time.Time
+}
+
+// RecycleBin returns the Recycle Bin retention rule that retains
+// the deleted managed snapshots of `.Job`, the rule with the longest
+// retention is returned, or a zero rule if no rule applies.
+//
+// A rule applies when it is available, is not pending unlock, has
+// no resource tags or one of them matches the `ebs-backup:job` tag,
+// and none of its exclusion tags match it.
+func (e *Engine) RecycleBin() (RecycleRule, error) {
+	var ret RecycleRule
+	var token *string
+
+	for {
+		resp, err := e.Rbin.ListRules(&recyclebin.ListRulesInput{
+			ResourceType: aws.String(recyclebin.ResourceTypeEbsSnapshot),
+			NextToken:    token,
+		})
+		if err != nil {
+			return ret, err
+		}
+
+		for _, r := range resp.Rules {
+			rule, exclude, err := e.rule(r.Identifier)
+			if err != nil {
+				return ret, err
+			}
+
+			if !e.retains(rule, exclude) {
+				continue
+			}
+
+			if d := retention(rule.RetentionPeriod); d > ret.Retention {
+				ret = RecycleRule{
+					ID:        aws.StringValue(rule.Identifier),
+					Retention: d,
+				}
+			}
+		}
+
+		if resp.NextToken == nil {
+			return ret, nil
+		}
+		token = resp.NextToken
+	}
+}
+
+// rule returns the retention rule `id` and its exclusion tags,
+// which the SDK does not model and are read from the response.
+func (e *Engine) rule(id *string) (*recyclebin.GetRuleOutput, []*recyclebin.ResourceTag, error) {
+	var exclusions struct {
+		ExcludeResourceTags []*recyclebin.ResourceTag
+	}
+
+	req, out := e.Rbin.GetRuleRequest(&recyclebin.GetRuleInput{
+		Identifier: id,
+	})
+
+	req.Handlers.Unmarshal.PushFront(func(r *request.Request) {
+		b, err := io.ReadAll(r.HTTPResponse.Body)
+		r.HTTPResponse.Body.Close()
+		if err != nil {
+			r.Error = err
+			return
+		}
+
+		r.HTTPResponse.Body = io.NopCloser(bytes.NewReader(b))
+		json.Unmarshal(b, &exclusions)
+	})
+
+	if err := req.Send(); err != nil {
+		return nil, nil, err
+	}
+
+	return out, exclusions.ExcludeResourceTags, nil
+}
+
+// retains returns true if `rule` retains the snapshots of `.Job`.
+//
+// A rule pending unlock is not relied on, it can be modified or
+// deleted once its unlock delay expires.
+func (e *Engine) retains(rule *recyclebin.GetRuleOutput, exclude []*recyclebin.ResourceTag) bool {
+	if aws.StringValue(rule.Status) != recyclebin.RuleStatusAvailable {
+		return false
+	}
+
+	if aws.StringValue(rule.LockState) == recyclebin.LockStatePendingUnlock {
+		return false
+	}
+
+	for _, t := range exclude {
+		if e.jobTag(t) {
+			return false
+		}
+	}
+
+	if len(rule.ResourceTags) == 0 {
+		return true
+	}
+
+	for _, t := range rule.ResourceTags {
+		if e.jobTag(t) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// jobTag returns true if the resource tag `t` matches the job
+// tag of the managed snapshots, an empty value matches any job.
+func (e *Engine) jobTag(t *recyclebin.ResourceTag) bool {
+	if aws.StringValue(t.ResourceTagKey) != tagJob {
+		return false
+	}
+
+	value := aws.StringValue(t.ResourceTagValue)
+	return value == "" || value == e.Job
+}
+
+// retention returns the duration of the retention period `p`.
+func retention(p *recyclebin.RetentionPeriod) time.Duration {
+	if p == nil || aws.StringValue(p.RetentionPeriodUnit) != recyclebin.RetentionPeriodUnitDays {
+		return 0
+	}
+
+	return time.Duration(aws.Int64Value(p.RetentionPeriodValue)) * 24 * time.Hour
+}
+
+// Recycled returns the snapshots deleted within `since` that are
+// in the Recycle Bin, sorted from the most recently deleted.
+//
+// Snapshots in the Recycle Bin have no tags, unless `all` is true
+// only the snapshots of volumes that still have managed snapshots
+// of `.Job` are returned.
+func (e *Engine) Recycled(since time.Duration, all bool) ([]Recycled, error) {
+	volumes := make(map[string]bool)
+
+	if !all {
+		set, err := e.jobSnapshots()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, s := range set {
+			volumes[s.VolumeID] = true
+		}
+	}
+
+	var ret []Recycled
+
+	err := e.EC2.ListSnapshotsInRecycleBinPages(&ec2.ListSnapshotsInRecycleBinInput{}, func(page *ec2.ListSnapshotsInRecycleBinOutput, last bool) bool {
+		for _, s := range page.Snapshots {
+			deleted := aws.TimeValue(s.RecycleBinEnterTime)
+
+			if since > 0 && now().Sub(deleted) > since {
+				continue
+			}
+
+			if !all && !volumes[aws.StringValue(s.VolumeId)] {
+				continue
+			}
+
+			ret = append(ret, Recycled{
+				SnapshotID:  aws.StringValue(s.SnapshotId),
+				VolumeID:    aws.StringValue(s.VolumeId),
+				Description: aws.StringValue(s.Description),
+				Deleted:     deleted,
+				Expires:     aws.TimeValue(s.RecycleBinExitTime),
+			})
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i].Deleted.After(ret[j].Deleted)
+	})
+
+	return ret, nil
+}
+
+// Undelete restores the snapshots `ids` from the Recycle Bin.
+func (e *Engine) Undelete(ids ...string) error {
+	for _, id := range ids {
+		_, err := e.EC2.RestoreSnapshotFromRecycleBin(&ec2.RestoreSnapshotFromRecycleBinInput{
+			SnapshotId: aws.String(id),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
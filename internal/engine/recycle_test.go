@@ -0,0 +1,194 @@
+package engine
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/client/metadata"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/recyclebin"
+	"github.com/aws/aws-sdk-go/service/recyclebin/recyclebiniface"
+	"github.com/stretchr/testify/assert"
+)
+
+type rbinMock struct {
+	recyclebiniface.RecycleBinAPI
+	rules    map[string]*recyclebin.GetRuleOutput
+	excluded map[string][]*recyclebin.ResourceTag
+}
+
+func (m rbinMock) ListRules(i *recyclebin.ListRulesInput) (*recyclebin.ListRulesOutput, error) {
+	var ret recyclebin.ListRulesOutput
+	for id := range m.rules {
+		ret.Rules = append(ret.Rules, &recyclebin.RuleSummary{Identifier: aws.String(id)})
+	}
+	return &ret, nil
+}
+
+// GetRuleRequest responds with the exclusion tags of the rule
+// in the body, which the SDK does not unmarshal.
+func (m rbinMock) GetRuleRequest(i *recyclebin.GetRuleInput) (*request.Request, *recyclebin.GetRuleOutput) {
+	out := &recyclebin.GetRuleOutput{}
+	body, _ := json.Marshal(map[string]interface{}{
+		"ExcludeResourceTags": m.excluded[*i.Identifier],
+	})
+
+	req := request.New(aws.Config{}, metadata.ClientInfo{}, request.Handlers{}, nil,
+		&request.Operation{Name: "GetRule"}, i, out)
+	req.Handlers.Send.PushBack(func(r *request.Request) {
+		r.HTTPResponse = &http.Response{StatusCode: 200, Body: io.NopCloser(bytes.NewReader(body))}
+	})
+	req.Handlers.Unmarshal.PushBack(func(r *request.Request) {
+		*out = *m.rules[*i.Identifier]
+	})
+
+	return req, out
+}
+
+func rule(id string, days int64, tags ...*recyclebin.ResourceTag) *recyclebin.GetRuleOutput {
+	return &recyclebin.GetRuleOutput{
+		Identifier: aws.String(id),
+		Status:     aws.String("available"),
+		RetentionPeriod: &recyclebin.RetentionPeriod{
+			RetentionPeriodUnit:  aws.String("DAYS"),
+			RetentionPeriodValue: aws.Int64(days),
+		},
+		ResourceTags: tags,
+	}
+}
+
+func jobResourceTag(value string) *recyclebin.ResourceTag {
+	return &recyclebin.ResourceTag{
+		ResourceTagKey:   aws.String(tagJob),
+		ResourceTagValue: aws.String(value),
+	}
+}
+
+func TestRecycleBin(t *testing.T) {
+	assert := assert.New(t)
+
+	pending := rule("rule-region", 30)
+	pending.Status = aws.String(recyclebin.RuleStatusPending)
+
+	e := New(Config{
+		Job: "db",
+		Rbin: rbinMock{rules: map[string]*recyclebin.GetRuleOutput{
+			"rule-db":     rule("rule-db", 7, jobResourceTag("db")),
+			"rule-any":    rule("rule-any", 3, jobResourceTag("")),
+			"rule-logs":   rule("rule-logs", 14, jobResourceTag("logs")),
+			"rule-other":  rule("rule-other", 21, &recyclebin.ResourceTag{ResourceTagKey: aws.String("Team")}),
+			"rule-region": pending,
+		}},
+	})
+
+	r, err := e.RecycleBin()
+	assert.NoError(err)
+	assert.Equal(RecycleRule{ID: "rule-db", Retention: 7 * 24 * time.Hour}, r)
+
+	e.Job = "web"
+	r, err = e.RecycleBin()
+	assert.NoError(err)
+	assert.Equal("rule-any", r.ID)
+
+	e.Rbin = rbinMock{}
+	r, err = e.RecycleBin()
+	assert.NoError(err)
+	assert.Equal(RecycleRule{}, r)
+}
+
+func TestRecycleBinExclusions(t *testing.T) {
+	assert := assert.New(t)
+
+	unlocking := rule("rule-unlocking", 30)
+	unlocking.LockState = aws.String(recyclebin.LockStatePendingUnlock)
+	locked := rule("rule-locked", 3)
+	locked.LockState = aws.String(recyclebin.LockStateLocked)
+
+	e := New(Config{
+		Job: "db",
+		Rbin: rbinMock{
+			rules: map[string]*recyclebin.GetRuleOutput{
+				"rule-unlocking": unlocking,
+				"rule-locked":    locked,
+				"rule-except-db": rule("rule-except-db", 14),
+				"rule-except":    rule("rule-except", 7),
+			},
+			excluded: map[string][]*recyclebin.ResourceTag{
+				"rule-except-db": {jobResourceTag("db")},
+				"rule-except":    {jobResourceTag("logs")},
+			},
+		},
+	})
+
+	r, err := e.RecycleBin()
+	assert.NoError(err)
+	assert.Equal(RecycleRule{ID: "rule-except", Retention: 7 * 24 * time.Hour}, r)
+
+	e.Job = "logs"
+	r, err = e.RecycleBin()
+	assert.NoError(err)
+	assert.Equal("rule-except-db", r.ID)
+}
+
+func TestRecycled(t *testing.T) {
+	assert := assert.New(t)
+	defer clock(time.Unix(86400*10, 0))()
+
+	info := func(id, volume string, age time.Duration) *ec2.SnapshotRecycleBinInfo {
+		return &ec2.SnapshotRecycleBinInfo{
+			SnapshotId:          aws.String(id),
+			VolumeId:            aws.String(volume),
+			RecycleBinEnterTime: aws.Time(now().Add(-age)),
+			RecycleBinExitTime:  aws.Time(now().Add(7*24*time.Hour - age)),
+		}
+	}
+
+	var restored []string
+
+	e := New(Config{
+		Job: "db",
+		EC2: mock{
+			DescribeSnapshotsFunc: func(req *ec2.DescribeSnapshotsInput) (*ec2.DescribeSnapshotsOutput, error) {
+				return &ec2.DescribeSnapshotsOutput{
+					Snapshots: []*ec2.Snapshot{managedSnapshot("snap-010", "vol-001", time.Hour)},
+				}, nil
+			},
+			ListSnapshotsInRecycleBinFunc: func(req *ec2.ListSnapshotsInRecycleBinInput) (*ec2.ListSnapshotsInRecycleBinOutput, error) {
+				return &ec2.ListSnapshotsInRecycleBinOutput{
+					Snapshots: []*ec2.SnapshotRecycleBinInfo{
+						info("snap-001", "vol-001", 3*24*time.Hour),
+						info("snap-002", "vol-001", time.Hour),
+						info("snap-003", "vol-002", time.Hour),
+					},
+				}, nil
+			},
+			RestoreSnapshotFromRecycleBinFunc: func(req *ec2.RestoreSnapshotFromRecycleBinInput) (*ec2.RestoreSnapshotFromRecycleBinOutput, error) {
+				restored = append(restored, *req.SnapshotId)
+				return &ec2.RestoreSnapshotFromRecycleBinOutput{}, nil
+			},
+		},
+	})
+
+	recycled, err := e.Recycled(24*time.Hour, false)
+	assert.NoError(err)
+	assert.Equal([]Recycled{{
+		SnapshotID: "snap-002",
+		VolumeID:   "vol-001",
+		Deleted:    now().Add(-time.Hour),
+		Expires:    now().Add(7*24*time.Hour - time.Hour),
+	}}, recycled)
+
+	recycled, err = e.Recycled(0, true)
+	assert.NoError(err)
+	assert.Equal(3, len(recycled))
+	assert.Equal("snap-001", recycled[2].SnapshotID)
+
+	assert.NoError(e.Undelete("snap-001", "snap-002"))
+	assert.Equal([]string{"snap-001", "snap-002"}, restored)
+}
@@ -0,0 +1,136 @@
+package engine
+
+import (
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+// Status is the backup coverage status of a volume.
+type Status string
+
+// Coverage statuses, from the worst to the best.
+const (
+	StatusInvalid  Status = "invalid"
+	StatusMissing  Status = "missing"
+	StatusStale    Status = "stale"
+	StatusShort    Status = "under-retained"
+	StatusExcluded Status = "excluded"
+	StatusOK       Status = "ok"
+)
+
+// Coverage is the backup coverage of a volume.
+//
+// Snapshots is the number of completed managed snapshots of
+// the volume, Limit the retention expected by its policy.
+// Newest and Age describe its most recent snapshot and are
+// zero when the volume has none.
+type Coverage struct {
+	VolumeID  string
+	Name      string
+	State     string
+	Snapshots int
+	Limit     int
+	Newest    time.Time
+	Age       time.Duration
+	Status    Status
+	Err       error
+}
+
+// Report returns the backup coverage of all the volumes
+// of the account, sorted by volume id.
+//
+// Volumes are joined with the completed snapshots tagged with
+// `ebs-backup:job`, copies in a DR region and AMI snapshots are
+// ignored. A volume is stale when its newest snapshot is older
+// than `.RPO`, and under-retained when it has fewer than `.Limit`
+// snapshots or the limit of its `ebs-backup:retain` tag. Volumes
+// tagged with `ebs-backup:skip` are excluded.
+func (e *Engine) Report() ([]Coverage, error) {
+	var volumes []*ec2.Volume
+
+	err := e.EC2.DescribeVolumesPages(&ec2.DescribeVolumesInput{}, func(page *ec2.DescribeVolumesOutput, last bool) bool {
+		volumes = append(volumes, page.Volumes...)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	byVolume := make(map[string][]Snapshot)
+
+	err = e.EC2.DescribeSnapshotsPages(&ec2.DescribeSnapshotsInput{
+		OwnerIds: aws.StringSlice([]string{"self"}),
+		Filters: []*ec2.Filter{
+			filter("tag-key", tagJob),
+			filter("status", ec2.SnapshotStateCompleted),
+		},
+	}, func(page *ec2.DescribeSnapshotsOutput, last bool) bool {
+		for _, s := range page.Snapshots {
+			if _, ok := tag(s.Tags, tagSourceSnapshot); ok {
+				continue
+			}
+
+			snap := snapshot(s)
+			if fromImage(snap) {
+				continue
+			}
+
+			byVolume[snap.VolumeID] = append(byVolume[snap.VolumeID], snap)
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make([]Coverage, 0, len(volumes))
+
+	for _, v := range volumes {
+		c := e.coverage(volume(v), byVolume[aws.StringValue(v.VolumeId)])
+		c.State = aws.StringValue(v.State)
+		ret = append(ret, c)
+	}
+
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i].VolumeID < ret[j].VolumeID
+	})
+
+	return ret, nil
+}
+
+// coverage returns the coverage of `v` given its snapshots `set`.
+func (e *Engine) coverage(v Volume, set []Snapshot) Coverage {
+	c := Coverage{
+		VolumeID:  v.ID,
+		Name:      v.Name,
+		Snapshots: len(set),
+	}
+
+	if s := newest(set); s != nil {
+		c.Newest = s.Created
+		c.Age = now().Sub(c.Newest)
+	}
+
+	p, err := e.policy(v)
+	c.Limit = p.Limit
+
+	switch {
+	case err != nil:
+		c.Status, c.Err = StatusInvalid, err
+	case p.Skip:
+		c.Status = StatusExcluded
+	case c.Newest.IsZero():
+		c.Status = StatusMissing
+	case e.RPO > 0 && c.Age > e.RPO:
+		c.Status = StatusStale
+	case c.Snapshots < p.Limit:
+		c.Status = StatusShort
+	default:
+		c.Status = StatusOK
+	}
+
+	return c
+}
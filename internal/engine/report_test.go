@@ -0,0 +1,78 @@
+package engine
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReport(t *testing.T) {
+	assert := assert.New(t)
+	defer clock(time.Unix(86400*100, 0))()
+
+	volume := func(id string, tags ...*ec2.Tag) *ec2.Volume {
+		return &ec2.Volume{
+			VolumeId: aws.String(id),
+			State:    aws.String("in-use"),
+			Tags:     tags,
+		}
+	}
+
+	e := New(Config{
+		Limit: 2,
+		RPO:   24 * time.Hour,
+		EC2: mock{
+			DescribeVolumesFunc: func(req *ec2.DescribeVolumesInput) (*ec2.DescribeVolumesOutput, error) {
+				return &ec2.DescribeVolumesOutput{
+					Volumes: []*ec2.Volume{
+						volume("vol-006", &ec2.Tag{Key: aws.String(tagRetain), Value: aws.String("x")}),
+						volume("vol-001", &ec2.Tag{Key: aws.String("Name"), Value: aws.String("db-1")}),
+						volume("vol-002"),
+						volume("vol-003"),
+						volume("vol-004"),
+						volume("vol-005", &ec2.Tag{Key: aws.String(tagSkip), Value: aws.String("true")}),
+					},
+				}, nil
+			},
+			DescribeSnapshotsFunc: func(req *ec2.DescribeSnapshotsInput) (*ec2.DescribeSnapshotsOutput, error) {
+				return &ec2.DescribeSnapshotsOutput{
+					Snapshots: []*ec2.Snapshot{
+						managedSnapshot("snap-001", "vol-001", 2*time.Hour),
+						managedSnapshot("snap-002", "vol-001", 26*time.Hour),
+						managedSnapshot("snap-003", "vol-003", 48*time.Hour),
+						managedSnapshot("snap-004", "vol-003", 72*time.Hour),
+						managedSnapshot("snap-005", "vol-004", time.Hour),
+						managedSnapshot("snap-006", "vol-002", time.Hour,
+							&ec2.Tag{Key: aws.String(tagSourceSnapshot), Value: aws.String("snap-000")}),
+					},
+				}, nil
+			},
+		},
+	})
+
+	report, err := e.Report()
+	assert.NoError(err)
+	assert.Equal(6, len(report))
+
+	assert.Equal(Coverage{
+		VolumeID:  "vol-001",
+		Name:      "db-1",
+		State:     "in-use",
+		Snapshots: 2,
+		Limit:     2,
+		Newest:    now().Add(-2 * time.Hour),
+		Age:       2 * time.Hour,
+		Status:    StatusOK,
+	}, report[0])
+
+	assert.Equal(StatusMissing, report[1].Status)
+	assert.Equal(StatusStale, report[2].Status)
+	assert.Equal(48*time.Hour, report[2].Age)
+	assert.Equal(StatusShort, report[3].Status)
+	assert.Equal(StatusExcluded, report[4].Status)
+	assert.Equal(StatusInvalid, report[5].Status)
+	assert.EqualError(report[5].Err, `invalid ebs-backup:retain tag "x"`)
+}
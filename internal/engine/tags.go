@@ -0,0 +1,136 @@
+package engine
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+// Tag set on every snapshot created by the engine,
+// its value is the configured `.Job`.
+const tagJob = "ebs-backup:job"
+
+// reserved are the tag key prefixes that are never copied
+// from a volume, `aws:` tags cannot be set by users and
+// `ebs-backup:` tags configure the volume, not its snapshots.
+var reserved = []string{"aws:", "ebs-backup:"}
+
+// TagRules configures how volume tags are copied to snapshots.
+//
+// Include and Exclude hold key patterns as understood by `path.Match`,
+// except that `*` also matches `/`. When Include is not empty only
+// matching keys are copied, keys matching Exclude are never copied.
+// Rename maps a volume tag key to the key it is copied to.
+type TagRules struct {
+	Include []string
+	Exclude []string
+	Rename  map[string]string
+}
+
+// Copy reports whether the volume tag `key` should be copied.
+func (r TagRules) copy(key string) bool {
+	for _, prefix := range reserved {
+		if strings.HasPrefix(key, prefix) {
+			return false
+		}
+	}
+
+	if len(r.Include) > 0 && !match(r.Include, key) {
+		return false
+	}
+
+	return !match(r.Exclude, key)
+}
+
+// Tags returns the tags to set on a snapshot of `v`.
+//
+// The tags are the volume tags, if the policy copies tags,
+// filtered and renamed by the configured `.TagRules`
+// followed by the configured `.Tags`, which are templates
+// executed with `m`, and the `ebs-backup:job` tag.
+// When a key is set twice the last value wins.
+func (e *Engine) tags(v Volume, p policy, m Meta) (map[string]string, error) {
+	tags := make(map[string]string)
+
+	if p.CopyTags {
+		keys := make([]string, 0, len(v.Tags))
+		for k := range v.Tags {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			if !e.TagRules.copy(k) {
+				continue
+			}
+
+			key := k
+			if name, ok := e.TagRules.Rename[k]; ok {
+				key = name
+			}
+
+			tags[key] = v.Tags[k]
+		}
+	}
+
+	extra := make([]string, 0, len(e.Tags))
+	for k := range e.Tags {
+		extra = append(extra, k)
+	}
+	sort.Strings(extra)
+
+	for _, k := range extra {
+		value, err := render(e.Tags[k], m)
+		if err != nil {
+			return nil, fmt.Errorf("tag %s: %s", k, err)
+		}
+		tags[k] = value
+	}
+
+	tags[tagJob] = e.Job
+	return tags, nil
+}
+
+// specs returns the tag specifications that apply `tags` to
+// a resource of type `typ` or nil if there are no tags.
+func specs(typ string, tags []*ec2.Tag) []*ec2.TagSpecification {
+	if len(tags) == 0 {
+		return nil
+	}
+
+	return []*ec2.TagSpecification{{
+		ResourceType: aws.String(typ),
+		Tags:         tags,
+	}}
+}
+
+// tag returns the value of tag `key` in `tags`.
+func tag(tags []*ec2.Tag, key string) (string, bool) {
+	for _, t := range tags {
+		if aws.StringValue(t.Key) == key {
+			return aws.StringValue(t.Value), true
+		}
+	}
+
+	return "", false
+}
+
+// match reports whether `key` matches one of `patterns`.
+//
+// Tag keys are not paths, `/` is swapped for a character that
+// cannot appear in keys so that `*` matches across slashes.
+func match(patterns []string, key string) bool {
+	key = strings.Replace(key, "/", "\x00", -1)
+
+	for _, p := range patterns {
+		if ok, _ := path.Match(strings.Replace(p, "/", "\x00", -1), key); ok {
+			return true
+		}
+	}
+
+	return false
+}
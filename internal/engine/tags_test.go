@@ -0,0 +1,89 @@
+package engine
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTags(t *testing.T) {
+	assert := assert.New(t)
+
+	e := New(Config{
+		Name: "db-*",
+		Tags: map[string]string{
+			"Team":   "storage",
+			"Source": "{{.VolumeID}}",
+		},
+		TagRules: TagRules{
+			Exclude: []string{"kubernetes.io/*"},
+			Rename:  map[string]string{"Name": "VolumeName"},
+		},
+	})
+
+	tags, err := e.tags(Volume{
+		Tags: map[string]string{
+			"Name":                               "db-1",
+			"Team":                               "data",
+			"aws:autoscaling:groupName":          "db",
+			"ebs-backup:retain":                  "3",
+			"kubernetes.io/created-for/pvc/name": "db",
+		},
+	}, policy{CopyTags: true}, Meta{VolumeID: "vol-xyz"})
+
+	assert.NoError(err)
+	assert.Equal(map[string]string{
+		"VolumeName":     "db-1",
+		"Team":           "storage",
+		"Source":         "vol-xyz",
+		"ebs-backup:job": "db-*",
+	}, tags)
+}
+
+func TestTagsInclude(t *testing.T) {
+	assert := assert.New(t)
+
+	e := New(Config{
+		Job: "db",
+		TagRules: TagRules{
+			Include: []string{"Name", "cost-*"},
+		},
+	})
+
+	tags, err := e.tags(Volume{
+		Tags: map[string]string{
+			"Name":        "db-1",
+			"cost-center": "42",
+			"Owner":       "ops",
+		},
+	}, policy{CopyTags: true}, Meta{})
+
+	assert.NoError(err)
+	assert.Equal(map[string]string{
+		"Name":           "db-1",
+		"cost-center":    "42",
+		"ebs-backup:job": "db",
+	}, tags)
+}
+
+func TestTagsNoCopy(t *testing.T) {
+	assert := assert.New(t)
+
+	e := New(Config{Job: "db"})
+
+	tags, err := e.tags(Volume{Tags: map[string]string{"Name": "db-1"}}, policy{}, Meta{})
+
+	assert.NoError(err)
+	assert.Equal(map[string]string{"ebs-backup:job": "db"}, tags)
+}
+
+func TestTagsTemplateErr(t *testing.T) {
+	assert := assert.New(t)
+
+	e := New(Config{
+		Tags: map[string]string{"Source": "{{.Volume}}"},
+	})
+
+	_, err := e.tags(Volume{}, policy{}, Meta{})
+	assert.Error(err)
+}
@@ -0,0 +1,50 @@
+package engine
+
+import (
+	"bytes"
+	"text/template"
+	"time"
+)
+
+// DefaultDescription is the default snapshot description template.
+const DefaultDescription = "ebs-backup {{.Job}}: {{.VolumeID}} ({{.Name}}) {{.InstanceID}}:{{.Device}}"
+
+// Meta holds the fields that snapshot description
+// and tag templates are executed with.
+type Meta struct {
+	VolumeID   string
+	Name       string
+	InstanceID string
+	Device     string
+	Job        string
+	Time       time.Time
+	Version    string
+}
+
+// Meta returns the template fields for a snapshot of `v`.
+func (e *Engine) meta(v Volume) Meta {
+	return Meta{
+		VolumeID:   v.ID,
+		Name:       v.Name,
+		InstanceID: v.InstanceID,
+		Device:     v.Device,
+		Job:        e.Job,
+		Time:       now().UTC(),
+		Version:    e.Version,
+	}
+}
+
+// Render executes the template `text` with `m`.
+func render(text string, m Meta) (string, error) {
+	t, err := template.New("").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, m); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
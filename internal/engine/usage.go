@@ -0,0 +1,185 @@
+package engine
+
+import (
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ebs"
+)
+
+// SnapshotUsage is the estimated storage of a snapshot.
+//
+// Bytes is the size of the blocks that changed since the previous
+// snapshot of the volume, or of all the blocks of the oldest one.
+type SnapshotUsage struct {
+	SnapshotID string
+	Time       time.Time
+	Bytes      int64
+}
+
+// Usage is the estimated snapshot storage of a volume.
+//
+// Snapshots are sorted from the oldest to the newest, Bytes is
+// their billable storage, the sum of their incremental sizes.
+// SimulatedBytes is the estimated billable storage had the
+// volume been retained with the simulated limit.
+type Usage struct {
+	VolumeID       string
+	Job            string
+	Snapshots      []SnapshotUsage
+	Bytes          int64
+	SimulatedBytes int64
+}
+
+// Usage estimates the storage of the completed managed snapshots
+// of each volume with the EBS direct APIs, and simulates it with
+// a retention of `limit` snapshots. The result is sorted by volume.
+// Archived snapshots are left out, the direct APIs cannot read them.
+//
+// With a limit lower than the number of snapshots the oldest ones
+// are dropped, the oldest retained snapshot then holds all of its
+// blocks. With a higher limit, the missing snapshots are assumed
+// to be the size of the average incremental snapshot.
+func (e *Engine) Usage(limit int) ([]Usage, error) {
+	set, err := e.jobSnapshots()
+	if err != nil {
+		return nil, err
+	}
+
+	byVolume := make(map[string][]Snapshot)
+
+	for _, s := range managed(set) {
+		if archived(s) {
+			continue
+		}
+		byVolume[s.VolumeID] = append(byVolume[s.VolumeID], s)
+	}
+
+	ret := make([]Usage, 0, len(byVolume))
+
+	for id, set := range byVolume {
+		u, err := e.usage(set, limit)
+		if err != nil {
+			return nil, err
+		}
+
+		u.VolumeID = id
+		ret = append(ret, u)
+	}
+
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i].VolumeID < ret[j].VolumeID
+	})
+
+	return ret, nil
+}
+
+// usage estimates the storage of the snapshots `set` of a volume.
+func (e *Engine) usage(set []Snapshot, limit int) (Usage, error) {
+	var u Usage
+
+	sorted := byTime(set)
+	sort.Sort(sort.Reverse(sorted))
+
+	for i, s := range sorted {
+		var n int64
+		var err error
+
+		if i == 0 {
+			n, err = e.blocks(s.ID)
+		} else {
+			n, err = e.changed(sorted[i-1].ID, s.ID)
+		}
+		if err != nil {
+			return u, err
+		}
+
+		u.Snapshots = append(u.Snapshots, SnapshotUsage{
+			SnapshotID: s.ID,
+			Time:       s.Created,
+			Bytes:      n,
+		})
+		u.Bytes += n
+	}
+
+	if len(sorted) > 0 {
+		u.Job = sorted[len(sorted)-1].Tags[tagJob]
+	}
+
+	switch n := len(u.Snapshots); {
+	case limit <= 0 || limit == n:
+		u.SimulatedBytes = u.Bytes
+	case limit < n:
+		first, err := e.blocks(u.Snapshots[n-limit].SnapshotID)
+		if err != nil {
+			return u, err
+		}
+
+		u.SimulatedBytes = first
+		for _, s := range u.Snapshots[n-limit+1:] {
+			u.SimulatedBytes += s.Bytes
+		}
+	default:
+		var avg int64
+		if n > 1 {
+			avg = (u.Bytes - u.Snapshots[0].Bytes) / int64(n-1)
+		}
+
+		u.SimulatedBytes = u.Bytes + avg*int64(limit-n)
+	}
+
+	return u, nil
+}
+
+// blocks returns the size of all the blocks of snapshot `id`.
+func (e *Engine) blocks(id string) (int64, error) {
+	var size int64
+	var token *string
+
+	for {
+		resp, err := e.EBS.ListSnapshotBlocks(&ebs.ListSnapshotBlocksInput{
+			SnapshotId: aws.String(id),
+			NextToken:  token,
+		})
+		if err != nil {
+			return 0, err
+		}
+
+		size += int64(len(resp.Blocks)) * aws.Int64Value(resp.BlockSize)
+
+		if resp.NextToken == nil {
+			return size, nil
+		}
+		token = resp.NextToken
+	}
+}
+
+// changed returns the size of the blocks of snapshot `second` that
+// were added or changed since snapshot `first`.
+func (e *Engine) changed(first, second string) (int64, error) {
+	var size int64
+	var token *string
+
+	for {
+		resp, err := e.EBS.ListChangedBlocks(&ebs.ListChangedBlocksInput{
+			FirstSnapshotId:  aws.String(first),
+			SecondSnapshotId: aws.String(second),
+			NextToken:        token,
+		})
+		if err != nil {
+			return 0, err
+		}
+
+		for _, b := range resp.ChangedBlocks {
+			if b.SecondBlockToken != nil {
+				size += aws.Int64Value(resp.BlockSize)
+			}
+		}
+
+		if resp.NextToken == nil {
+			return size, nil
+		}
+		token = resp.NextToken
+	}
+}
@@ -0,0 +1,143 @@
+package engine
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ebs"
+	"github.com/aws/aws-sdk-go/service/ebs/ebsiface"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/stretchr/testify/assert"
+)
+
+type ebsMock struct {
+	ebsiface.EBSAPI
+	ListSnapshotBlocksFunc func(*ebs.ListSnapshotBlocksInput) (*ebs.ListSnapshotBlocksOutput, error)
+	ListChangedBlocksFunc  func(*ebs.ListChangedBlocksInput) (*ebs.ListChangedBlocksOutput, error)
+}
+
+func (m ebsMock) ListSnapshotBlocks(i *ebs.ListSnapshotBlocksInput) (*ebs.ListSnapshotBlocksOutput, error) {
+	return m.ListSnapshotBlocksFunc(i)
+}
+
+func (m ebsMock) ListChangedBlocks(i *ebs.ListChangedBlocksInput) (*ebs.ListChangedBlocksOutput, error) {
+	return m.ListChangedBlocksFunc(i)
+}
+
+// usageEngine returns an engine with three snapshots of a volume,
+// of 4 blocks, 1 changed block and 2 changed blocks of 1KiB.
+func usageEngine() Engine {
+	full := map[string]int{"snap-001": 4, "snap-002": 4, "snap-003": 5}
+	changed := map[string]int{"snap-002": 1, "snap-003": 2}
+
+	return New(Config{
+		EC2: mock{
+			DescribeSnapshotsFunc: func(req *ec2.DescribeSnapshotsInput) (*ec2.DescribeSnapshotsOutput, error) {
+				return &ec2.DescribeSnapshotsOutput{
+					Snapshots: []*ec2.Snapshot{
+						managedSnapshot("snap-003", "vol-001", time.Hour),
+						managedSnapshot("snap-001", "vol-001", 3*time.Hour),
+						managedSnapshot("snap-002", "vol-001", 2*time.Hour),
+					},
+				}, nil
+			},
+		},
+		EBS: ebsMock{
+			ListSnapshotBlocksFunc: func(req *ebs.ListSnapshotBlocksInput) (*ebs.ListSnapshotBlocksOutput, error) {
+				// Paginate one block at a time.
+				var i int
+				if req.NextToken != nil {
+					i = len(*req.NextToken)
+				}
+
+				resp := &ebs.ListSnapshotBlocksOutput{
+					BlockSize: aws.Int64(1024),
+					Blocks:    []*ebs.Block{{BlockIndex: aws.Int64(int64(i))}},
+				}
+				if i+1 < full[*req.SnapshotId] {
+					resp.NextToken = aws.String(string(make([]byte, i+1)))
+				}
+				return resp, nil
+			},
+			ListChangedBlocksFunc: func(req *ebs.ListChangedBlocksInput) (*ebs.ListChangedBlocksOutput, error) {
+				resp := &ebs.ListChangedBlocksOutput{
+					BlockSize: aws.Int64(1024),
+					// A block that only exists in the first snapshot is not counted.
+					ChangedBlocks: []*ebs.ChangedBlock{{FirstBlockToken: aws.String("a")}},
+				}
+				for i := 0; i < changed[*req.SecondSnapshotId]; i++ {
+					resp.ChangedBlocks = append(resp.ChangedBlocks, &ebs.ChangedBlock{SecondBlockToken: aws.String("b")})
+				}
+				return resp, nil
+			},
+		},
+	})
+}
+
+func TestUsage(t *testing.T) {
+	assert := assert.New(t)
+	defer clock(time.Unix(86400, 0))()
+
+	e := usageEngine()
+
+	usage, err := e.Usage(0)
+	assert.NoError(err)
+	assert.Equal([]Usage{{
+		VolumeID: "vol-001",
+		Job:      "db",
+		Snapshots: []SnapshotUsage{
+			{SnapshotID: "snap-001", Time: now().Add(-3 * time.Hour), Bytes: 4096},
+			{SnapshotID: "snap-002", Time: now().Add(-2 * time.Hour), Bytes: 1024},
+			{SnapshotID: "snap-003", Time: now().Add(-time.Hour), Bytes: 2048},
+		},
+		Bytes:          7168,
+		SimulatedBytes: 7168,
+	}}, usage)
+}
+
+func TestUsageSimulate(t *testing.T) {
+	assert := assert.New(t)
+	defer clock(time.Unix(86400, 0))()
+
+	e := usageEngine()
+
+	usage, err := e.Usage(2)
+	assert.NoError(err)
+	assert.Equal(int64(4096+2048), usage[0].SimulatedBytes)
+
+	usage, err = e.Usage(5)
+	assert.NoError(err)
+	assert.Equal(int64(7168+2*1536), usage[0].SimulatedBytes)
+}
+
+func TestUsageSkipsArchived(t *testing.T) {
+	assert := assert.New(t)
+	defer clock(time.Unix(86400, 0))()
+
+	e := usageEngine()
+	describe := e.EC2.(mock).DescribeSnapshotsFunc
+	e.EC2 = mock{
+		DescribeSnapshotsFunc: func(req *ec2.DescribeSnapshotsInput) (*ec2.DescribeSnapshotsOutput, error) {
+			resp, err := describe(req)
+			if err != nil {
+				return nil, err
+			}
+
+			tier := managedSnapshot("snap-000", "vol-001", 5*time.Hour)
+			tier.StorageTier = aws.String(ec2.StorageTierArchive)
+			tagged := managedSnapshot("snap-004", "vol-001", 4*time.Hour,
+				&ec2.Tag{Key: aws.String(tagArchived), Value: aws.String("2020-01-01T00:00:00Z")})
+
+			resp.Snapshots = append(resp.Snapshots, tier, tagged)
+			return resp, nil
+		},
+	}
+
+	usage, err := e.Usage(0)
+	assert.NoError(err)
+	assert.Len(usage, 1)
+	assert.Len(usage[0].Snapshots, 3)
+	assert.Equal("snap-001", usage[0].Snapshots[0].SnapshotID)
+	assert.Equal(int64(7168), usage[0].Bytes)
+}
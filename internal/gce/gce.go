@@ -0,0 +1,408 @@
+// Package gce implements engine.Provider for Google Compute
+// Engine persistent disks on top of the Compute REST API.
+package gce
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/segmentio/ebs-backup/internal/engine"
+)
+
+// DefaultEndpoint is the default base URL of the Compute API.
+const DefaultEndpoint = "https://compute.googleapis.com/compute/v1"
+
+// now returns the current time, it is replaced in tests.
+var now = time.Now
+
+// Provider is an engine.Provider of the persistent disks of a zone.
+//
+// Disks are selected by `Labels`, all of which must match, and by
+// the name pattern, see `Volumes`. Snapshots are global resources
+// named after their disk and labeled like EBS snapshots are tagged,
+// see `labels`. Token returns the OAuth2 access token of requests,
+// see `MetadataToken`. Endpoint defaults to `DefaultEndpoint` and
+// Client to `http.DefaultClient`.
+type Provider struct {
+	Project  string
+	Zone     string
+	Labels   map[string]string
+	Endpoint string
+	Client   *http.Client
+	Token    func() (string, error)
+}
+
+// Error is an error response of the Compute API.
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// Error implements error.
+func (e *Error) Error() string {
+	return fmt.Sprintf("compute: %d %s", e.Code, e.Message)
+}
+
+// Is maps the status code of the error to the engine error kinds.
+func (e *Error) Is(target error) bool {
+	switch e.Code {
+	case http.StatusTooManyRequests:
+		return target == engine.ErrThrottled
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return target == engine.ErrPermissionDenied
+	default:
+		return false
+	}
+}
+
+// disk is a persistent disk resource.
+type disk struct {
+	Name     string            `json:"name"`
+	SelfLink string            `json:"selfLink"`
+	Labels   map[string]string `json:"labels"`
+	Users    []string          `json:"users"`
+}
+
+// snapshot is a snapshot resource.
+type snapshot struct {
+	Name              string            `json:"name"`
+	Description       string            `json:"description,omitempty"`
+	SourceDisk        string            `json:"sourceDisk,omitempty"`
+	Status            string            `json:"status,omitempty"`
+	CreationTimestamp string            `json:"creationTimestamp,omitempty"`
+	Labels            map[string]string `json:"labels,omitempty"`
+	LabelFingerprint  string            `json:"labelFingerprint,omitempty"`
+}
+
+// Volumes implements engine.Provider.
+//
+// Disks must carry all of `.Labels` and be attached to an
+// instance. The selector's `.Name` is a pattern matched with
+// `path.Match` against disk names, `.VolumeIDs`, if any, are
+// disk names that replace it, devices are ignored.
+func (p Provider) Volumes(s engine.Selector) ([]engine.Volume, error) {
+	q := url.Values{}
+	if f := p.filter(); f != "" {
+		q.Set("filter", f)
+	}
+
+	var ret []engine.Volume
+
+	err := p.list(p.zonal("disks"), q, func(raw json.RawMessage) error {
+		var d disk
+		if err := json.Unmarshal(raw, &d); err != nil {
+			return err
+		}
+
+		if p.selected(d, s) {
+			ret = append(ret, volume(d))
+		}
+
+		return nil
+	})
+
+	return ret, err
+}
+
+// Snapshots implements engine.Provider.
+func (p Provider) Snapshots(id string) ([]engine.Snapshot, error) {
+	q := url.Values{}
+	q.Set("filter", fmt.Sprintf(`sourceDisk eq ".*/zones/%s/disks/%s"`, regexp.QuoteMeta(p.Zone), regexp.QuoteMeta(id)))
+
+	var ret []engine.Snapshot
+
+	err := p.list(p.global("snapshots"), q, func(raw json.RawMessage) error {
+		var s snapshot
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return err
+		}
+
+		if path.Base(s.SourceDisk) != id || !strings.Contains(s.SourceDisk, "/zones/"+p.Zone+"/") {
+			return nil
+		}
+
+		if state(s.Status) != "" {
+			ret = append(ret, engineSnapshot(s))
+		}
+
+		return nil
+	})
+
+	return ret, err
+}
+
+// CreateSnapshot implements engine.Provider.
+//
+// The snapshot is named after the disk and the current time,
+// the method does not wait for the operation to complete.
+func (p Provider) CreateSnapshot(v engine.Volume, description string, tags map[string]string) (engine.Snapshot, error) {
+	t := now().UTC()
+
+	s := snapshot{
+		Name:        name(v.ID, t),
+		Description: description,
+		Labels:      labels(tags),
+	}
+
+	err := p.do("POST", p.zonal("disks", v.ID, "createSnapshot"), nil, s, nil)
+	if err != nil {
+		return engine.Snapshot{}, err
+	}
+
+	return engine.Snapshot{
+		ID:       s.Name,
+		VolumeID: v.ID,
+		State:    engine.SnapshotPending,
+		Created:  t,
+		Tags:     tags,
+	}, nil
+}
+
+// TagSnapshot implements engine.Provider, `tags`
+// are merged into the labels of the snapshot.
+func (p Provider) TagSnapshot(id string, tags map[string]string) error {
+	var s snapshot
+	if err := p.do("GET", p.global("snapshots", id), nil, nil, &s); err != nil {
+		return err
+	}
+
+	merged := make(map[string]string, len(s.Labels)+len(tags))
+	for k, v := range s.Labels {
+		merged[k] = v
+	}
+	for k, v := range labels(tags) {
+		merged[k] = v
+	}
+
+	return p.do("POST", p.global("snapshots", id, "setLabels"), nil, map[string]interface{}{
+		"labels":           merged,
+		"labelFingerprint": s.LabelFingerprint,
+	}, nil)
+}
+
+// DeleteSnapshot implements engine.Provider.
+func (p Provider) DeleteSnapshot(id string) error {
+	return p.do("DELETE", p.global("snapshots", id), nil, nil, nil)
+}
+
+// CopySnapshot implements engine.Provider, snapshots are
+// global resources so there is nothing to copy them to.
+func (p Provider) CopySnapshot(engine.Snapshot, string, map[string]string) (engine.Snapshot, error) {
+	return engine.Snapshot{}, engine.ErrNotSupported
+}
+
+// selected reports whether `d` is selected by `s` and `.Labels`.
+func (p Provider) selected(d disk, s engine.Selector) bool {
+	if len(d.Users) == 0 {
+		return false
+	}
+
+	for k, v := range p.Labels {
+		if d.Labels[k] != v {
+			return false
+		}
+	}
+
+	if len(s.VolumeIDs) == 0 {
+		ok, _ := path.Match(s.Name, d.Name)
+		return s.Name == "" || ok
+	}
+
+	for _, id := range s.VolumeIDs {
+		if id == d.Name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// filter returns the list filter that matches `.Labels`.
+func (p Provider) filter() string {
+	keys := make([]string, 0, len(p.Labels))
+	for k := range p.Labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	terms := make([]string, 0, len(keys))
+	for _, k := range keys {
+		terms = append(terms, fmt.Sprintf("(labels.%s = %q)", k, p.Labels[k]))
+	}
+
+	return strings.Join(terms, " AND ")
+}
+
+// zonal returns the path of a zonal resource.
+func (p Provider) zonal(elem ...string) string {
+	return "/" + path.Join(append([]string{"projects", p.Project, "zones", p.Zone}, elem...)...)
+}
+
+// global returns the path of a global resource.
+func (p Provider) global(elem ...string) string {
+	return "/" + path.Join(append([]string{"projects", p.Project, "global"}, elem...)...)
+}
+
+// endpoint returns the base URL of the API.
+func (p Provider) endpoint() string {
+	if p.Endpoint == "" {
+		return DefaultEndpoint
+	}
+	return strings.TrimSuffix(p.Endpoint, "/")
+}
+
+// list calls `fn` with each item of the list at `path`, following pages.
+func (p Provider) list(path string, q url.Values, fn func(json.RawMessage) error) error {
+	for {
+		var page struct {
+			Items         []json.RawMessage `json:"items"`
+			NextPageToken string            `json:"nextPageToken"`
+		}
+
+		if err := p.do("GET", path, q, nil, &page); err != nil {
+			return err
+		}
+
+		for _, item := range page.Items {
+			if err := fn(item); err != nil {
+				return err
+			}
+		}
+
+		if page.NextPageToken == "" {
+			return nil
+		}
+		q.Set("pageToken", page.NextPageToken)
+	}
+}
+
+// do sends a request to `path` with the JSON body `in`, if not nil,
+// and decodes the response into `out`, if not nil.
+func (p Provider) do(method, path string, q url.Values, in, out interface{}) error {
+	var body io.Reader
+
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(b)
+	}
+
+	u := p.endpoint() + path
+	if len(q) > 0 {
+		u += "?" + q.Encode()
+	}
+
+	req, err := http.NewRequest(method, u, body)
+	if err != nil {
+		return err
+	}
+
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	if p.Token != nil {
+		token, err := p.Token()
+		if err != nil {
+			return fmt.Errorf("token: %s", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	client := p.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		var e struct {
+			Error *Error `json:"error"`
+		}
+
+		if err := json.NewDecoder(resp.Body).Decode(&e); err != nil || e.Error == nil {
+			return &Error{Code: resp.StatusCode, Message: resp.Status}
+		}
+
+		e.Error.Code = resp.StatusCode
+		return e.Error
+	}
+
+	if out == nil {
+		return nil
+	}
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// volume returns the engine volume of `d`.
+func volume(d disk) engine.Volume {
+	v := engine.Volume{
+		ID:   d.Name,
+		Name: d.Name,
+		Tags: tags(d.Labels),
+	}
+
+	if len(d.Users) > 0 {
+		v.InstanceID = path.Base(d.Users[0])
+	}
+
+	return v
+}
+
+// engineSnapshot returns the engine snapshot of `s`.
+func engineSnapshot(s snapshot) engine.Snapshot {
+	created, _ := time.Parse(time.RFC3339, s.CreationTimestamp)
+
+	return engine.Snapshot{
+		ID:       s.Name,
+		VolumeID: path.Base(s.SourceDisk),
+		State:    state(s.Status),
+		Created:  created,
+		Tags:     tags(s.Labels),
+	}
+}
+
+// state returns the engine state of snapshot `status`, or an
+// empty state for a snapshot being deleted, which is left out.
+func state(status string) string {
+	switch status {
+	case "READY":
+		return engine.SnapshotCompleted
+	case "FAILED":
+		return engine.SnapshotError
+	case "DELETING":
+		return ""
+	default:
+		// CREATING and UPLOADING.
+		return engine.SnapshotPending
+	}
+}
+
+// name returns the name of a snapshot of disk `id` taken at `t`,
+// resource names are at most 63 characters long.
+func name(id string, t time.Time) string {
+	suffix := t.Format("-20060102-150405")
+
+	if max := 63 - len(suffix); len(id) > max {
+		id = strings.TrimRight(id[:max], "-")
+	}
+
+	return id + suffix
+}
@@ -0,0 +1,337 @@
+package gce
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/segmentio/ebs-backup/internal/engine"
+	"github.com/stretchr/testify/assert"
+)
+
+// fake is a fake Compute API serving the disks and
+// snapshots of project "p" in zone "z".
+type fake struct {
+	sync.Mutex
+	disks     []disk
+	snapshots map[string]snapshot
+	requests  []string
+	token     string
+}
+
+func (f *fake) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.Lock()
+	defer f.Unlock()
+
+	f.requests = append(f.requests, r.Method+" "+r.URL.Path)
+
+	if r.Header.Get("Authorization") != "Bearer "+f.token {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"error": map[string]interface{}{"code": 401, "message": "invalid credentials"},
+		})
+		return
+	}
+
+	const zonal, global = "/projects/p/zones/z/disks", "/projects/p/global/snapshots"
+
+	switch path := r.URL.Path; {
+	case r.Method == "GET" && path == zonal:
+		// One disk per page to exercise paging.
+		i := 0
+		if token := r.URL.Query().Get("pageToken"); token != "" {
+			i = int(token[0] - '0')
+		}
+		page := map[string]interface{}{"items": f.disks[i : i+1]}
+		if i+1 < len(f.disks) {
+			page["nextPageToken"] = string(rune('0' + i + 1))
+		}
+		json.NewEncoder(w).Encode(page)
+
+	case r.Method == "POST" && strings.HasSuffix(path, "/createSnapshot"):
+		var s snapshot
+		json.NewDecoder(r.Body).Decode(&s)
+		s.SourceDisk = "https://www.googleapis.com/compute/v1" + strings.TrimSuffix(path, "/createSnapshot")
+		s.Status = "CREATING"
+		s.CreationTimestamp = now().Format(time.RFC3339)
+		s.LabelFingerprint = "abc"
+		f.snapshots[s.Name] = s
+		json.NewEncoder(w).Encode(map[string]string{"kind": "compute#operation"})
+
+	case r.Method == "GET" && path == global:
+		items := make([]snapshot, 0, len(f.snapshots))
+		for _, s := range f.snapshots {
+			items = append(items, s)
+		}
+		sort.Slice(items, func(i, j int) bool { return items[i].Name < items[j].Name })
+		json.NewEncoder(w).Encode(map[string]interface{}{"items": items})
+
+	case r.Method == "GET" && strings.HasPrefix(path, global+"/"):
+		json.NewEncoder(w).Encode(f.snapshots[strings.TrimPrefix(path, global+"/")])
+
+	case r.Method == "POST" && strings.HasSuffix(path, "/setLabels"):
+		var req struct {
+			Labels           map[string]string `json:"labels"`
+			LabelFingerprint string            `json:"labelFingerprint"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		name := strings.TrimSuffix(strings.TrimPrefix(path, global+"/"), "/setLabels")
+		s := f.snapshots[name]
+		if req.LabelFingerprint != s.LabelFingerprint {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			return
+		}
+		s.Labels = req.Labels
+		f.snapshots[name] = s
+		json.NewEncoder(w).Encode(map[string]string{"kind": "compute#operation"})
+
+	case r.Method == "DELETE" && strings.HasPrefix(path, global+"/"):
+		name := strings.TrimPrefix(path, global+"/")
+		if _, ok := f.snapshots[name]; !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		delete(f.snapshots, name)
+		json.NewEncoder(w).Encode(map[string]string{"kind": "compute#operation"})
+
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// ready returns a ready snapshot of disk `d` taken `age` before 1970-01-02.
+func ready(name, d string, age time.Duration, labels map[string]string) snapshot {
+	return snapshot{
+		Name:              name,
+		SourceDisk:        "https://www.googleapis.com/compute/v1/projects/p/zones/z/disks/" + d,
+		Status:            "READY",
+		CreationTimestamp: time.Unix(86400, 0).UTC().Add(-age).Format(time.RFC3339),
+		Labels:            labels,
+		LabelFingerprint:  "abc",
+	}
+}
+
+// server starts `f` and returns a provider of its disks, and a
+// func that stops it. The current time is 1970-01-02 meanwhile.
+func server(f *fake) (Provider, func()) {
+	now = func() time.Time { return time.Unix(86400, 0).UTC() }
+
+	f.token = "secret"
+	s := httptest.NewServer(f)
+
+	p := Provider{
+		Project:  "p",
+		Zone:     "z",
+		Labels:   map[string]string{"backup": "daily"},
+		Endpoint: s.URL,
+		Client:   s.Client(),
+		Token:    StaticToken("secret"),
+	}
+
+	return p, func() {
+		s.Close()
+		now = time.Now
+	}
+}
+
+func TestVolumes(t *testing.T) {
+	assert := assert.New(t)
+
+	f := &fake{disks: []disk{
+		{Name: "db-1", Labels: map[string]string{"backup": "daily", "ebs-backup_retain": "3"}, Users: []string{"projects/p/zones/z/instances/i-1"}},
+		{Name: "db-2", Labels: map[string]string{"backup": "weekly"}, Users: []string{"projects/p/zones/z/instances/i-2"}},
+		{Name: "db-3", Labels: map[string]string{"backup": "daily"}},
+		{Name: "web-1", Labels: map[string]string{"backup": "daily"}, Users: []string{"projects/p/zones/z/instances/i-3"}},
+	}}
+
+	p, done := server(f)
+	defer done()
+
+	volumes, err := p.Volumes(engine.Selector{Name: "db-*"})
+	assert.NoError(err)
+	assert.Equal([]engine.Volume{{
+		ID:         "db-1",
+		Name:       "db-1",
+		InstanceID: "i-1",
+		Tags:       map[string]string{"backup": "daily", "ebs-backup:retain": "3"},
+	}}, volumes)
+	assert.Equal(4, len(f.requests))
+
+	p.Labels["env"] = "prod"
+	assert.Equal(`(labels.backup = "daily") AND (labels.env = "prod")`, p.filter())
+}
+
+func TestCreateSnapshot(t *testing.T) {
+	assert := assert.New(t)
+
+	f := &fake{snapshots: map[string]snapshot{}}
+
+	p, done := server(f)
+	defer done()
+
+	s, err := p.CreateSnapshot(engine.Volume{ID: "db-1"}, "backup of db-1", map[string]string{
+		"team":           "Data",
+		"ebs-backup:job": "db",
+	})
+	assert.NoError(err)
+	assert.Equal(engine.Snapshot{
+		ID:       "db-1-19700102-000000",
+		VolumeID: "db-1",
+		State:    engine.SnapshotPending,
+		Created:  time.Unix(86400, 0).UTC(),
+		Tags:     map[string]string{"team": "Data", "ebs-backup:job": "db"},
+	}, s)
+
+	created := f.snapshots["db-1-19700102-000000"]
+	assert.Equal("backup of db-1", created.Description)
+	assert.Equal(map[string]string{"team": "data", "ebs-backup_job": "db"}, created.Labels)
+	assert.Equal([]string{"POST /projects/p/zones/z/disks/db-1/createSnapshot"}, f.requests)
+
+	snapshots, err := p.Snapshots("db-1")
+	assert.NoError(err)
+	assert.Equal(1, len(snapshots))
+	assert.Equal(engine.SnapshotPending, snapshots[0].State)
+
+	assert.NoError(p.DeleteSnapshot("db-1-19700102-000000"))
+	assert.Empty(f.snapshots)
+}
+
+func TestTagSnapshot(t *testing.T) {
+	assert := assert.New(t)
+
+	f := &fake{snapshots: map[string]snapshot{
+		"db-1-a": ready("db-1-a", "db-1", time.Hour, map[string]string{"team": "data"}),
+	}}
+
+	p, done := server(f)
+	defer done()
+
+	assert.NoError(p.TagSnapshot("db-1-a", map[string]string{"ebs-backup:error": "2020-01-01T00:00:00Z"}))
+	assert.Equal(map[string]string{
+		"team":             "data",
+		"ebs-backup_error": "2020-01-01t00_00_00z",
+	}, f.snapshots["db-1-a"].Labels)
+
+	snapshots, err := p.Snapshots("db-1")
+	assert.NoError(err)
+	assert.Equal(1, len(snapshots))
+	assert.Equal(engine.SnapshotCompleted, snapshots[0].State)
+	assert.Equal("2020-01-01t00_00_00z", snapshots[0].Tags["ebs-backup:error"])
+}
+
+func TestSnapshotsDeleting(t *testing.T) {
+	assert := assert.New(t)
+
+	deleting := ready("db-1-a", "db-1", 2*time.Hour, nil)
+	deleting.Status = "DELETING"
+	uploading := ready("db-1-b", "db-1", time.Hour, nil)
+	uploading.Status = "UPLOADING"
+
+	f := &fake{snapshots: map[string]snapshot{
+		"db-1-a": deleting,
+		"db-1-b": uploading,
+	}}
+
+	p, done := server(f)
+	defer done()
+
+	snapshots, err := p.Snapshots("db-1")
+	assert.NoError(err)
+	assert.Equal(1, len(snapshots))
+	assert.Equal("db-1-b", snapshots[0].ID)
+	assert.Equal(engine.SnapshotPending, snapshots[0].State)
+}
+
+func TestErrors(t *testing.T) {
+	assert := assert.New(t)
+
+	f := &fake{snapshots: map[string]snapshot{}}
+
+	p, done := server(f)
+	defer done()
+
+	err := p.DeleteSnapshot("missing")
+	assert.Equal(&Error{Code: 404, Message: "404 Not Found"}, err)
+
+	p.Token = StaticToken("wrong")
+	_, err = p.Volumes(engine.Selector{})
+	assert.EqualError(err, "compute: 401 invalid credentials")
+	assert.True(errors.Is(err, engine.ErrPermissionDenied))
+
+	_, err = p.CopySnapshot(engine.Snapshot{}, "us-east1", nil)
+	assert.Equal(engine.ErrNotSupported, err)
+}
+
+func TestMetadataToken(t *testing.T) {
+	assert := assert.New(t)
+
+	at := time.Unix(86400, 0)
+	now = func() time.Time { return at }
+	defer func() { now = time.Now }()
+
+	tokens := []string{"abc", "def"}
+	var calls int
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal("Google", r.Header.Get("Metadata-Flavor"))
+		fmt.Fprintf(w, `{"access_token":%q,"expires_in":3599,"token_type":"Bearer"}`, tokens[calls])
+		calls++
+	}))
+	defer s.Close()
+
+	fn := MetadataToken(s.Client(), s.URL)
+
+	token, err := fn()
+	assert.NoError(err)
+	assert.Equal("abc", token)
+
+	at = at.Add(50 * time.Minute)
+	token, err = fn()
+	assert.NoError(err)
+	assert.Equal("abc", token)
+	assert.Equal(1, calls)
+
+	at = at.Add(9 * time.Minute)
+	token, err = fn()
+	assert.NoError(err)
+	assert.Equal("def", token)
+	assert.Equal(2, calls)
+}
+
+func TestName(t *testing.T) {
+	assert := assert.New(t)
+
+	at := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	assert.Equal("db-1-20200102-030405", name("db-1", at))
+	assert.Equal(63, len(name(strings.Repeat("d", 70), at)))
+}
+
+func TestLabels(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal(map[string]string{
+		"name":             "db",
+		"team":             "ops",
+		"ebs-backup_job":   "db_daily",
+		"ebs-backup_error": "2020-01-01t00_00_00z",
+	}, labels(map[string]string{
+		"Name":             "db",
+		"team":             "data",
+		"Team":             "ops",
+		"ebs-backup:job":   "DB.daily",
+		"ebs-backup:error": "2020-01-01T00:00:00Z",
+		"":                 "x",
+	}))
+
+	assert.Equal(map[string]string{
+		"ebs-backup:retain": "3",
+		"team":              "data_eng",
+	}, tags(map[string]string{"ebs-backup_retain": "3", "team": "data_eng"}))
+}
@@ -0,0 +1,82 @@
+package gce
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Label keys and values are at most 63 lowercase letters, digits,
+// underscores and dashes, so the `ebs-backup:` prefix of engine tags
+// is stored as `ebs-backup_` and other characters are replaced.
+//
+// The conversion is lossy and `tags` does not restore the original
+// tags: the keys and values of `Config.Tags`, e.g. a `Name` tag
+// becomes the `name` label, the job name of `ebs-backup:job` and the
+// time of `ebs-backup:error` are lowercased, e.g. `2020-01-01t00_00_00z`,
+// and long values are truncated. The engine only reads the policy labels of
+// disks and the presence of `ebs-backup:error` back, and labels copied
+// from disks are valid as is, so neither is affected. Keys that are
+// equal once sanitized keep the value of the first key in order.
+const (
+	tagPrefix   = "ebs-backup:"
+	labelPrefix = "ebs-backup_"
+	maxLabel    = 63
+)
+
+// labels returns the engine `tags` as labels.
+func labels(tags map[string]string) map[string]string {
+	ret := make(map[string]string, len(tags))
+
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		k := key
+		if strings.HasPrefix(k, tagPrefix) {
+			k = labelPrefix + strings.TrimPrefix(k, tagPrefix)
+		}
+
+		k = sanitize(k)
+		if _, ok := ret[k]; k != "" && !ok {
+			ret[k] = sanitize(tags[key])
+		}
+	}
+
+	return ret
+}
+
+// tags returns `labels` as engine tags.
+func tags(labels map[string]string) map[string]string {
+	ret := make(map[string]string, len(labels))
+
+	for k, v := range labels {
+		if strings.HasPrefix(k, labelPrefix) {
+			k = tagPrefix + strings.TrimPrefix(k, labelPrefix)
+		}
+		ret[k] = v
+	}
+
+	return ret
+}
+
+// sanitize returns `s` lowercased with invalid characters
+// replaced by underscores, truncated to 63 characters.
+func sanitize(s string) string {
+	s = strings.Map(func(r rune) rune {
+		r = unicode.ToLower(r)
+		if r == '-' || r == '_' || ('a' <= r && r <= 'z') || ('0' <= r && r <= '9') {
+			return r
+		}
+		return '_'
+	}, s)
+
+	if len(s) > maxLabel {
+		s = s[:maxLabel]
+	}
+
+	return s
+}
@@ -0,0 +1,75 @@
+package gce
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// DefaultMetadataURL is the URL of the access token of the
+// default service account on the GCE metadata server.
+const DefaultMetadataURL = "http://metadata.google.internal/computeMetadata/v1/instance/service-accounts/default/token"
+
+// tokenMargin is how long before it expires a token is refreshed.
+const tokenMargin = time.Minute
+
+// MetadataToken returns a Token func that fetches the access
+// token of the instance's service account from `url`. The token
+// is cached until shortly before it expires.
+func MetadataToken(client *http.Client, url string) func() (string, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	var mu sync.Mutex
+	var token string
+	var expires time.Time
+
+	return func() (string, error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if token != "" && now().Before(expires) {
+			return token, nil
+		}
+
+		req, err := http.NewRequest("GET", url, nil)
+		if err != nil {
+			return "", err
+		}
+		req.Header.Set("Metadata-Flavor", "Google")
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return "", err
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			return "", fmt.Errorf("metadata server: %s", resp.Status)
+		}
+
+		var t struct {
+			AccessToken string `json:"access_token"`
+			ExpiresIn   int64  `json:"expires_in"`
+		}
+
+		if err := json.NewDecoder(resp.Body).Decode(&t); err != nil {
+			return "", err
+		}
+
+		token = t.AccessToken
+		expires = now().Add(time.Duration(t.ExpiresIn)*time.Second - tokenMargin)
+
+		return token, nil
+	}
+}
+
+// StaticToken returns a Token func that always returns `token`.
+func StaticToken(token string) func() (string, error) {
+	return func() (string, error) {
+		return token, nil
+	}
+}
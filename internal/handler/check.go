@@ -0,0 +1,132 @@
+package handler
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/segmentio/ebs-backup/internal/engine"
+)
+
+// Check states and their Nagios plugin exit codes.
+const (
+	OK       = 0
+	Warning  = 1
+	Critical = 2
+	Unknown  = 3
+)
+
+// states are the names of the check states.
+var states = [...]string{"OK", "WARNING", "CRITICAL", "UNKNOWN"}
+
+// CheckSummary counts the checked volumes by status.
+type CheckSummary struct {
+	Volumes       int   `json:"Volumes"`
+	OK            int   `json:"OK"`
+	Missing       int   `json:"Missing"`
+	Stale         int   `json:"Stale"`
+	UnderRetained int   `json:"UnderRetained"`
+	Excluded      int   `json:"Excluded"`
+	Invalid       int   `json:"Invalid"`
+	MaxAgeMS      int64 `json:"MaxAgeMS"`
+}
+
+// CheckResponse is the result of an RPO check.
+//
+// Code is the Nagios exit code of the check, missing and stale
+// volumes, or a selector that matches no volume, are critical
+// while under-retained volumes and invalid policies are warnings.
+type CheckResponse struct {
+	Version int          `json:"Version"`
+	Name    string       `json:"Name"`
+	RPOMS   int64        `json:"RPOMS"`
+	State   string       `json:"State"`
+	Code    int          `json:"Code"`
+	Volumes []Coverage   `json:"Volumes"`
+	Summary CheckSummary `json:"Summary"`
+}
+
+// NewCheckResponse returns the check response for the coverage
+// `results` of the volumes of job `name`.
+func NewCheckResponse(name string, rpo time.Duration, results []engine.Coverage) CheckResponse {
+	r := CheckResponse{
+		Version: Version,
+		Name:    name,
+		RPOMS:   milliseconds(rpo),
+		Volumes: make([]Coverage, 0, len(results)),
+		Summary: CheckSummary{Volumes: len(results)},
+	}
+
+	if len(results) == 0 {
+		r.Code = Critical
+	}
+
+	for _, c := range results {
+		code := OK
+
+		switch c.Status {
+		case engine.StatusOK:
+			r.Summary.OK++
+		case engine.StatusMissing:
+			r.Summary.Missing++
+			code = Critical
+		case engine.StatusStale:
+			r.Summary.Stale++
+			code = Critical
+		case engine.StatusShort:
+			r.Summary.UnderRetained++
+			code = Warning
+		case engine.StatusExcluded:
+			r.Summary.Excluded++
+		case engine.StatusInvalid:
+			r.Summary.Invalid++
+			code = Warning
+		}
+
+		if code > r.Code {
+			r.Code = code
+		}
+
+		if ms := milliseconds(c.Age); c.Status != engine.StatusExcluded && ms > r.Summary.MaxAgeMS {
+			r.Summary.MaxAgeMS = ms
+		}
+
+		r.Volumes = append(r.Volumes, NewCoverage(c))
+	}
+
+	r.State = states[r.Code]
+	return r
+}
+
+// String returns the Nagios plugin output of the check, a
+// status line with performance data followed by a line for
+// each volume that is not in compliance.
+func (r CheckResponse) String() string {
+	var b strings.Builder
+	s := r.Summary
+
+	switch {
+	case s.Volumes == 0:
+		fmt.Fprintf(&b, "EBS-BACKUP %s - no volume matched", r.State)
+	default:
+		fmt.Fprintf(&b, "EBS-BACKUP %s - %d of %d volumes in compliance", r.State, s.OK+s.Excluded, s.Volumes)
+	}
+
+	fmt.Fprintf(&b, " | volumes=%d ok=%d missing=%d stale=%d under_retained=%d invalid=%d max_age=%ds\n",
+		s.Volumes, s.OK, s.Missing, s.Stale, s.UnderRetained, s.Invalid, s.MaxAgeMS/1000)
+
+	for _, v := range r.Volumes {
+		switch v.Status {
+		case string(engine.StatusOK), string(engine.StatusExcluded):
+			continue
+		}
+
+		fmt.Fprintf(&b, "%s %s: %s", v.VolumeID, v.Name, v.Status)
+		if v.Error != "" {
+			fmt.Fprintf(&b, " (%s)", v.Error)
+		}
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
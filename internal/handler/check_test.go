@@ -0,0 +1,59 @@
+package handler
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/segmentio/ebs-backup/internal/engine"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewCheckResponse(t *testing.T) {
+	assert := assert.New(t)
+
+	r := NewCheckResponse("db-*", 24*time.Hour, []engine.Coverage{
+		{VolumeID: "vol-001", Status: engine.StatusOK, Age: time.Hour, Newest: time.Unix(0, 0)},
+		{VolumeID: "vol-002", Status: engine.StatusShort, Age: 2 * time.Hour, Newest: time.Unix(0, 0)},
+		{VolumeID: "vol-003", Status: engine.StatusExcluded, Age: 72 * time.Hour},
+	})
+
+	assert.Equal(Warning, r.Code)
+	assert.Equal("WARNING", r.State)
+	assert.Equal(int64(86400000), r.RPOMS)
+	assert.Equal("1970-01-01T00:00:00Z", r.Volumes[0].Newest)
+	assert.Equal(CheckSummary{
+		Volumes:       3,
+		OK:            1,
+		UnderRetained: 1,
+		Excluded:      1,
+		MaxAgeMS:      7200000,
+	}, r.Summary)
+	assert.Equal("EBS-BACKUP WARNING - 2 of 3 volumes in compliance"+
+		" | volumes=3 ok=1 missing=0 stale=0 under_retained=1 invalid=0 max_age=7200s\n"+
+		"vol-002 : under-retained\n", r.String())
+}
+
+func TestNewCheckResponseCritical(t *testing.T) {
+	assert := assert.New(t)
+
+	r := NewCheckResponse("db-*", time.Hour, []engine.Coverage{
+		{VolumeID: "vol-001", Name: "db-1", Status: engine.StatusStale, Age: 2 * time.Hour},
+		{VolumeID: "vol-002", Status: engine.StatusInvalid, Err: errors.New("boom")},
+	})
+
+	assert.Equal(Critical, r.Code)
+	assert.Equal("EBS-BACKUP CRITICAL - 0 of 2 volumes in compliance"+
+		" | volumes=2 ok=0 missing=0 stale=1 under_retained=0 invalid=1 max_age=7200s\n"+
+		"vol-001 db-1: stale\n"+
+		"vol-002 : invalid (boom)\n", r.String())
+}
+
+func TestNewCheckResponseNoVolumes(t *testing.T) {
+	assert := assert.New(t)
+
+	r := NewCheckResponse("db-*", time.Hour, nil)
+	assert.Equal(Critical, r.Code)
+	assert.Equal([]Coverage{}, r.Volumes)
+	assert.Contains(r.String(), "EBS-BACKUP CRITICAL - no volume matched")
+}
@@ -0,0 +1,44 @@
+package handler
+
+import (
+	"time"
+
+	"github.com/segmentio/ebs-backup/internal/engine"
+)
+
+// Coverage describes the backup coverage of an EBS volume.
+type Coverage struct {
+	VolumeID  string `json:"VolumeID"`
+	Name      string `json:"Name"`
+	State     string `json:"State"`
+	Status    string `json:"Status"`
+	Snapshots int    `json:"Snapshots"`
+	Limit     int    `json:"Limit"`
+	Newest    string `json:"Newest"`
+	AgeMS     int64  `json:"AgeMS"`
+	Error     string `json:"Error"`
+}
+
+// NewCoverage returns the coverage for the given engine coverage `c`,
+// Newest is formatted as RFC3339 and empty if the volume has no snapshot.
+func NewCoverage(c engine.Coverage) Coverage {
+	ret := Coverage{
+		VolumeID:  c.VolumeID,
+		Name:      c.Name,
+		State:     c.State,
+		Status:    string(c.Status),
+		Snapshots: c.Snapshots,
+		Limit:     c.Limit,
+		AgeMS:     milliseconds(c.Age),
+	}
+
+	if !c.Newest.IsZero() {
+		ret.Newest = c.Newest.UTC().Format(time.RFC3339)
+	}
+
+	if c.Err != nil {
+		ret.Error = c.Err.Error()
+	}
+
+	return ret
+}
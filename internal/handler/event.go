@@ -0,0 +1,20 @@
+package handler
+
+// Event is the optional payload the Lambda function may be invoked with.
+//
+// All fields are optional and override the environment config for a
+// single invocation. CloudWatch scheduled events carry none of these
+// fields, so a scheduled invocation runs the configured job unchanged.
+//
+// Description and Tags are templates, Tags are added to the
+// configured tags.
+type Event struct {
+	VolumeIDs   []string          `json:"VolumeIDs"`
+	Name        string            `json:"Name"`
+	Devices     []string          `json:"Devices"`
+	Limit       int               `json:"Limit"`
+	CopyTags    *bool             `json:"CopyTags"`
+	DryRun      bool              `json:"DryRun"`
+	Description string            `json:"Description"`
+	Tags        map[string]string `json:"Tags"`
+}
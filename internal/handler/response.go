@@ -1,12 +1,180 @@
 package handler
 
-// Result describes the information about a successful EBS volume snapshot.
+import (
+	"time"
+
+	"github.com/segmentio/ebs-backup/internal/engine"
+)
+
+// Version is the version of the response schema.
+//
+// Fields may be added to the schema without changing the version,
+// renaming or removing a field requires a new version.
+const Version = 2
+
+// Result describes the information about an EBS volume backup.
 type Result struct {
-	Name       string `json:"Name"`
-	SnapshotID string `json:"SnapshotID"`
-	VolumeID   string `json:"VolumeID"`
-	Error      string `json:"Error"`
+	Name                  string            `json:"Name"`
+	SnapshotID            string            `json:"SnapshotID"`
+	VolumeID              string            `json:"VolumeID"`
+	InstanceID            string            `json:"InstanceID"`
+	ImageID               string            `json:"ImageID"`
+	DeregisteredImages    []string          `json:"DeregisteredImages"`
+	DeletedSnapshots      []string          `json:"DeletedSnapshots"`
+	FailedDeletions       map[string]string `json:"FailedDeletions"`
+	CopiedTags            bool              `json:"CopiedTags"`
+	Skipped               string            `json:"Skipped"`
+	PendingSnapshot       string            `json:"PendingSnapshot"`
+	PendingProgress       string            `json:"PendingProgress"`
+	PendingAgeMS          int64             `json:"PendingAgeMS"`
+	ErroredSnapshots      []string          `json:"ErroredSnapshots"`
+	HeldSnapshots         []string          `json:"HeldSnapshots"`
+	ProtectedSnapshots    []string          `json:"ProtectedSnapshots"`
+	FastRestoreSnapshot   string            `json:"FastRestoreSnapshot"`
+	FastRestoreDisabled   []string          `json:"FastRestoreDisabled"`
+	ArchivedSnapshots     []string          `json:"ArchivedSnapshots"`
+	RecycleBinRule        string            `json:"RecycleBinRule"`
+	RecycleBinRetentionMS int64             `json:"RecycleBinRetentionMS"`
+	DRRegion              string            `json:"DRRegion"`
+	CopiedSnapshot        string            `json:"CopiedSnapshot"`
+	DeletedCopies         []string          `json:"DeletedCopies"`
+	DurationMS            int64             `json:"DurationMS"`
+	Error                 string            `json:"Error"`
+	ErrorPhase            string            `json:"ErrorPhase"`
+	ErrorCode             string            `json:"ErrorCode"`
+	Retryable             bool              `json:"Retryable"`
+}
+
+// Summary summarizes the results of a run.
+type Summary struct {
+	Volumes    int   `json:"Volumes"`
+	Created    int   `json:"Created"`
+	Deleted    int   `json:"Deleted"`
+	Skipped    int   `json:"Skipped"`
+	Errored    int   `json:"Errored"`
+	Archived   int   `json:"Archived"`
+	Held       int   `json:"Held"`
+	Protected  int   `json:"Protected"`
+	Failed     int   `json:"Failed"`
+	DurationMS int64 `json:"DurationMS"`
+}
+
+// Response contains the Results of a run and their Summary.
+type Response struct {
+	Version int      `json:"Version"`
+	Name    string   `json:"Name"`
+	DryRun  bool     `json:"DryRun"`
+	Results []Result `json:"Results"`
+	Summary Summary  `json:"Summary"`
 }
 
-// Response contains a list of Results.
-type Response []Result
+// NewResponse returns the response for the given engine `results`
+// of a run of job `name` which took `d`.
+func NewResponse(name string, dryRun bool, results []engine.Result, d time.Duration) Response {
+	r := Response{
+		Version: Version,
+		Name:    name,
+		DryRun:  dryRun,
+		Results: make([]Result, 0, len(results)),
+		Summary: Summary{
+			Volumes:    len(results),
+			DurationMS: milliseconds(d),
+		},
+	}
+
+	for _, res := range results {
+		result := Result{
+			Name:                  name,
+			SnapshotID:            res.CreatedSnapshot,
+			VolumeID:              res.VolumeID,
+			InstanceID:            res.InstanceID,
+			ImageID:               res.CreatedImage,
+			DeregisteredImages:    res.DeregisteredImages,
+			DeletedSnapshots:      res.DeletedSnapshots,
+			FailedDeletions:       engine.FailedDeletions(res.Err),
+			CopiedTags:            res.CopiedTags,
+			Skipped:               res.Skipped,
+			PendingSnapshot:       res.PendingSnapshot,
+			PendingProgress:       res.PendingProgress,
+			PendingAgeMS:          milliseconds(res.PendingAge),
+			ErroredSnapshots:      res.ErroredSnapshots,
+			HeldSnapshots:         res.HeldSnapshots,
+			ProtectedSnapshots:    res.ProtectedSnapshots,
+			FastRestoreSnapshot:   res.FastRestoreSnapshot,
+			FastRestoreDisabled:   res.FastRestoreDisabled,
+			ArchivedSnapshots:     res.ArchivedSnapshots,
+			RecycleBinRule:        res.RecycleBinRule,
+			RecycleBinRetentionMS: milliseconds(res.RecycleBinRetention),
+			DRRegion:              res.DRRegion,
+			CopiedSnapshot:        res.CopiedSnapshot,
+			DeletedCopies:         res.DeletedCopies,
+			DurationMS:            milliseconds(res.Duration),
+		}
+
+		if result.DeletedSnapshots == nil {
+			result.DeletedSnapshots = []string{}
+		}
+
+		if result.FailedDeletions == nil {
+			result.FailedDeletions = map[string]string{}
+		}
+
+		if result.ErroredSnapshots == nil {
+			result.ErroredSnapshots = []string{}
+		}
+
+		if result.DeregisteredImages == nil {
+			result.DeregisteredImages = []string{}
+		}
+
+		if result.FastRestoreDisabled == nil {
+			result.FastRestoreDisabled = []string{}
+		}
+
+		if result.HeldSnapshots == nil {
+			result.HeldSnapshots = []string{}
+		}
+
+		if result.ProtectedSnapshots == nil {
+			result.ProtectedSnapshots = []string{}
+		}
+
+		if result.ArchivedSnapshots == nil {
+			result.ArchivedSnapshots = []string{}
+		}
+
+		if result.DeletedCopies == nil {
+			result.DeletedCopies = []string{}
+		}
+
+		if res.CreatedSnapshot != "" || res.CreatedImage != "" {
+			r.Summary.Created++
+		}
+
+		if res.Skipped != "" {
+			r.Summary.Skipped++
+		}
+
+		if res.Err != nil {
+			result.Error = res.Err.Error()
+			result.ErrorPhase = string(engine.ErrorPhase(res.Err))
+			result.ErrorCode = engine.ErrorCode(res.Err)
+			result.Retryable = engine.Retryable(res.Err)
+			r.Summary.Failed++
+		}
+
+		r.Summary.Deleted += len(res.DeletedSnapshots)
+		r.Summary.Errored += len(res.ErroredSnapshots)
+		r.Summary.Archived += len(res.ArchivedSnapshots)
+		r.Summary.Held += len(res.HeldSnapshots)
+		r.Summary.Protected += len(res.ProtectedSnapshots)
+		r.Results = append(r.Results, result)
+	}
+
+	return r
+}
+
+// milliseconds returns `d` in milliseconds.
+func milliseconds(d time.Duration) int64 {
+	return int64(d / time.Millisecond)
+}
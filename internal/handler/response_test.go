@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/segmentio/ebs-backup/internal/engine"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewResponse(t *testing.T) {
+	assert := assert.New(t)
+
+	r := NewResponse("db-*", false, []engine.Result{
+		{
+			VolumeID:           "vol-001",
+			CreatedSnapshot:    "snap-003",
+			DeletedSnapshots:   []string{"snap-001", "snap-002"},
+			CopiedTags:         true,
+			ErroredSnapshots:   []string{"snap-000"},
+			ArchivedSnapshots:  []string{"snap-004"},
+			HeldSnapshots:      []string{"snap-005"},
+			ProtectedSnapshots: []string{"snap-006"},
+			Duration:           1500 * time.Millisecond,
+		},
+		{
+			VolumeID: "vol-002",
+			Skipped:  "recent snapshot",
+		},
+		{
+			InstanceID:         "i-001",
+			CreatedImage:       "ami-002",
+			DeregisteredImages: []string{"ami-001"},
+		},
+		{
+			VolumeID: "vol-003",
+			Err:      errors.New("boom"),
+		},
+	}, 2*time.Second)
+
+	assert.Equal(Version, r.Version)
+	assert.Equal(4, len(r.Results))
+	assert.Equal("db-*", r.Results[0].Name)
+	assert.Equal("snap-003", r.Results[0].SnapshotID)
+	assert.Equal(int64(1500), r.Results[0].DurationMS)
+	assert.Equal([]string{}, r.Results[1].DeletedSnapshots)
+	assert.Equal(map[string]string{}, r.Results[1].FailedDeletions)
+	assert.Equal([]string{}, r.Results[1].ErroredSnapshots)
+	assert.Equal([]string{}, r.Results[1].ArchivedSnapshots)
+	assert.Equal([]string{}, r.Results[1].ProtectedSnapshots)
+	assert.Equal("recent snapshot", r.Results[1].Skipped)
+	assert.Equal("i-001", r.Results[2].InstanceID)
+	assert.Equal("ami-002", r.Results[2].ImageID)
+	assert.Equal([]string{"ami-001"}, r.Results[2].DeregisteredImages)
+	assert.Equal("boom", r.Results[3].Error)
+	assert.Equal("", r.Results[3].ErrorPhase)
+	assert.Equal("unknown", r.Results[3].ErrorCode)
+	assert.False(r.Results[3].Retryable)
+	assert.Equal("", r.Results[0].ErrorCode)
+	assert.Equal(Summary{
+		Volumes:    4,
+		Created:    2,
+		Deleted:    2,
+		Skipped:    1,
+		Errored:    1,
+		Archived:   1,
+		Held:       1,
+		Protected:  1,
+		Failed:     1,
+		DurationMS: 2000,
+	}, r.Summary)
+}
+
+func TestResponseFailedDeletions(t *testing.T) {
+	assert := assert.New(t)
+
+	r := NewResponse("db-*", false, []engine.Result{
+		{
+			VolumeID:         "vol-001",
+			DeletedSnapshots: []string{"snap-001"},
+			Err: &engine.Error{
+				Phase: engine.PhasePrune,
+				Kind:  engine.ErrPrune,
+				Err: engine.DeleteErrors{
+					{SnapshotID: "snap-002", Err: errors.New("boom")},
+				},
+			},
+		},
+	}, 0)
+
+	assert.Equal([]string{"snap-001"}, r.Results[0].DeletedSnapshots)
+	assert.Equal(map[string]string{"snap-002": "boom"}, r.Results[0].FailedDeletions)
+	assert.Equal("prune failed: 1 snapshot(s) not deleted: snap-002: boom", r.Results[0].Error)
+	assert.Equal("prune", r.Results[0].ErrorPhase)
+	assert.Equal("prune", r.Results[0].ErrorCode)
+	assert.False(r.Results[0].Retryable)
+	assert.Equal(1, r.Summary.Deleted)
+	assert.Equal(1, r.Summary.Failed)
+}
+
+func TestResponseJSON(t *testing.T) {
+	assert := assert.New(t)
+
+	b, err := json.Marshal(NewResponse("db-*", true, nil, 0))
+	assert.NoError(err)
+	assert.Equal(`{"Version":2,"Name":"db-*","DryRun":true,"Results":[],`+
+		`"Summary":{"Volumes":0,"Created":0,"Deleted":0,"Skipped":0,"Errored":0,"Archived":0,"Held":0,"Protected":0,"Failed":0,"DurationMS":0}}`, string(b))
+}
@@ -0,0 +1,93 @@
+package lock
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/segmentio/ebs-backup/internal/engine"
+)
+
+// DynamoDB is a Locker that uses conditional writes to a DynamoDB table.
+//
+// The table must have a string hash key named `VolumeID`. Items hold
+// the `Owner` of the lock and its expiry time as a unix timestamp in
+// `Expires`, which may be used as the table's TTL attribute.
+type DynamoDB struct {
+	API   dynamodbiface.DynamoDBAPI
+	Table string
+	Owner string
+	TTL   time.Duration
+}
+
+// Lock implements engine.Locker.
+//
+// The lock is acquired when there is no item for the volume
+// or its lock has expired.
+func (d DynamoDB) Lock(volumeID string) (func() error, error) {
+	t := now()
+
+	_, err := d.API.PutItem(&dynamodb.PutItemInput{
+		TableName: aws.String(d.Table),
+		Item: map[string]*dynamodb.AttributeValue{
+			"VolumeID": {S: aws.String(volumeID)},
+			"Owner":    {S: aws.String(d.Owner)},
+			"Expires":  {N: aws.String(unix(t.Add(ttl(d.TTL))))},
+		},
+		ConditionExpression: aws.String("attribute_not_exists(VolumeID) OR Expires < :now"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":now": {N: aws.String(unix(t))},
+		},
+	})
+	if isConditionFailed(err) {
+		return nil, engine.ErrLocked
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return func() error {
+		_, err := d.API.DeleteItem(&dynamodb.DeleteItemInput{
+			TableName: aws.String(d.Table),
+			Key: map[string]*dynamodb.AttributeValue{
+				"VolumeID": {S: aws.String(volumeID)},
+			},
+			ConditionExpression: aws.String("#owner = :owner"),
+			ExpressionAttributeNames: map[string]*string{
+				"#owner": aws.String("Owner"),
+			},
+			ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+				":owner": {S: aws.String(d.Owner)},
+			},
+		})
+
+		// The lock expired and was taken by another run.
+		if isConditionFailed(err) {
+			return nil
+		}
+
+		return err
+	}, nil
+}
+
+// isConditionFailed reports whether `err` is a failed conditional write.
+func isConditionFailed(err error) bool {
+	aerr, ok := err.(awserr.Error)
+	return ok && aerr.Code() == dynamodb.ErrCodeConditionalCheckFailedException
+}
+
+// unix returns `t` as a unix timestamp string.
+func unix(t time.Time) string {
+	return strconv.FormatInt(t.Unix(), 10)
+}
+
+// ttl returns `d`, or the DefaultTTL if `d` is zero.
+func ttl(d time.Duration) time.Duration {
+	if d == 0 {
+		return DefaultTTL
+	}
+	return d
+}
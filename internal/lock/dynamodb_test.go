@@ -0,0 +1,99 @@
+package lock
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/segmentio/ebs-backup/internal/engine"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDynamoDBLock(t *testing.T) {
+	assert := assert.New(t)
+	defer func() { now = time.Now }()
+	now = func() time.Time { return time.Unix(1000, 0) }
+
+	var put *dynamodb.PutItemInput
+	var del *dynamodb.DeleteItemInput
+
+	l := DynamoDB{
+		Table: "locks",
+		Owner: "host/1/abc",
+		TTL:   time.Minute,
+		API: dynamoMock{
+			PutItemFunc: func(i *dynamodb.PutItemInput) (*dynamodb.PutItemOutput, error) {
+				put = i
+				return nil, nil
+			},
+			DeleteItemFunc: func(i *dynamodb.DeleteItemInput) (*dynamodb.DeleteItemOutput, error) {
+				del = i
+				return nil, nil
+			},
+		},
+	}
+
+	unlock, err := l.Lock("vol-xyz")
+	assert.NoError(err)
+	assert.Equal("locks", *put.TableName)
+	assert.Equal("vol-xyz", *put.Item["VolumeID"].S)
+	assert.Equal("host/1/abc", *put.Item["Owner"].S)
+	assert.Equal("1060", *put.Item["Expires"].N)
+	assert.Equal("1000", *put.ExpressionAttributeValues[":now"].N)
+
+	assert.NoError(unlock())
+	assert.Equal("vol-xyz", *del.Key["VolumeID"].S)
+	assert.Equal("host/1/abc", *del.ExpressionAttributeValues[":owner"].S)
+}
+
+func TestDynamoDBLocked(t *testing.T) {
+	assert := assert.New(t)
+
+	l := DynamoDB{
+		Table: "locks",
+		API: dynamoMock{
+			PutItemFunc: func(i *dynamodb.PutItemInput) (*dynamodb.PutItemOutput, error) {
+				return nil, awserr.New(dynamodb.ErrCodeConditionalCheckFailedException, "failed", nil)
+			},
+		},
+	}
+
+	_, err := l.Lock("vol-xyz")
+	assert.Equal(engine.ErrLocked, err)
+}
+
+func TestDynamoDBUnlockExpired(t *testing.T) {
+	assert := assert.New(t)
+
+	l := DynamoDB{
+		Table: "locks",
+		API: dynamoMock{
+			PutItemFunc: func(i *dynamodb.PutItemInput) (*dynamodb.PutItemOutput, error) {
+				return nil, nil
+			},
+			DeleteItemFunc: func(i *dynamodb.DeleteItemInput) (*dynamodb.DeleteItemOutput, error) {
+				return nil, awserr.New(dynamodb.ErrCodeConditionalCheckFailedException, "failed", nil)
+			},
+		},
+	}
+
+	unlock, err := l.Lock("vol-xyz")
+	assert.NoError(err)
+	assert.NoError(unlock())
+}
+
+type dynamoMock struct {
+	dynamodbiface.DynamoDBAPI
+	PutItemFunc    func(*dynamodb.PutItemInput) (*dynamodb.PutItemOutput, error)
+	DeleteItemFunc func(*dynamodb.DeleteItemInput) (*dynamodb.DeleteItemOutput, error)
+}
+
+func (m dynamoMock) PutItem(i *dynamodb.PutItemInput) (*dynamodb.PutItemOutput, error) {
+	return m.PutItemFunc(i)
+}
+
+func (m dynamoMock) DeleteItem(i *dynamodb.DeleteItemInput) (*dynamodb.DeleteItemOutput, error) {
+	return m.DeleteItemFunc(i)
+}
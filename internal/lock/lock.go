@@ -0,0 +1,29 @@
+// Package lock implements engine.Locker on top of
+// DynamoDB conditional writes and EC2 volume tags.
+package lock
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"time"
+)
+
+// DefaultTTL is the default duration of a lock, a lock that is not
+// released, e.g. because the run crashed, expires after its TTL.
+const DefaultTTL = 15 * time.Minute
+
+// now returns the current time, it is replaced in tests.
+var now = time.Now
+
+// Owner returns a string that identifies this run,
+// the hostname, the process id and a random suffix.
+func Owner() string {
+	host, _ := os.Hostname()
+
+	b := make([]byte, 4)
+	rand.Read(b)
+
+	return fmt.Sprintf("%s/%d/%s", host, os.Getpid(), hex.EncodeToString(b))
+}
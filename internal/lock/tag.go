@@ -0,0 +1,118 @@
+package lock
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+	"github.com/segmentio/ebs-backup/internal/engine"
+)
+
+// Tag holding the lease of a volume, its value is the owner
+// of the lease and its expiry time as a unix timestamp.
+const tagLease = "ebs-backup:lock"
+
+// sleep pauses the current goroutine, it is replaced in tests.
+var sleep = time.Sleep
+
+// Tag is a Locker that stores a lease in a tag of the volume,
+// it requires no infrastructure besides EC2.
+//
+// EC2 tags have no conditional writes, so the lease is written
+// and read back after `Settle` to detect a concurrent writer.
+// This makes double-acquisition unlikely but not impossible,
+// use DynamoDB where strict exclusion is required.
+type Tag struct {
+	EC2    ec2iface.EC2API
+	Owner  string
+	TTL    time.Duration
+	Settle time.Duration
+}
+
+// Lock implements engine.Locker.
+func (l Tag) Lock(volumeID string) (func() error, error) {
+	owner, expires, err := l.lease(volumeID)
+	if err != nil {
+		return nil, err
+	}
+
+	if owner != "" && owner != l.Owner && now().Before(expires) {
+		return nil, engine.ErrLocked
+	}
+
+	value := fmt.Sprintf("%s %d", l.Owner, now().Add(ttl(l.TTL)).Unix())
+
+	_, err = l.EC2.CreateTags(&ec2.CreateTagsInput{
+		Resources: aws.StringSlice([]string{volumeID}),
+		Tags: []*ec2.Tag{{
+			Key:   aws.String(tagLease),
+			Value: aws.String(value),
+		}},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sleep(l.Settle)
+
+	if owner, _, err = l.lease(volumeID); err != nil {
+		return nil, err
+	}
+
+	if owner != l.Owner {
+		return nil, engine.ErrLocked
+	}
+
+	return func() error {
+		// DeleteTags only deletes the tag if its value
+		// still matches, i.e. the lease was not taken over.
+		_, err := l.EC2.DeleteTags(&ec2.DeleteTagsInput{
+			Resources: aws.StringSlice([]string{volumeID}),
+			Tags: []*ec2.Tag{{
+				Key:   aws.String(tagLease),
+				Value: aws.String(value),
+			}},
+		})
+		return err
+	}, nil
+}
+
+// lease returns the current lease of the volume `id`,
+// the owner is empty if the volume has no lease.
+func (l Tag) lease(id string) (string, time.Time, error) {
+	resp, err := l.EC2.DescribeVolumes(&ec2.DescribeVolumesInput{
+		VolumeIds: aws.StringSlice([]string{id}),
+	})
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	if len(resp.Volumes) == 0 {
+		return "", time.Time{}, fmt.Errorf("volume %s not found", id)
+	}
+
+	for _, t := range resp.Volumes[0].Tags {
+		if aws.StringValue(t.Key) != tagLease {
+			continue
+		}
+
+		value := aws.StringValue(t.Value)
+		i := strings.LastIndex(value, " ")
+		if i < 0 {
+			return "", time.Time{}, nil
+		}
+
+		sec, err := strconv.ParseInt(value[i+1:], 10, 64)
+		if err != nil {
+			return "", time.Time{}, nil
+		}
+
+		return value[:i], time.Unix(sec, 0), nil
+	}
+
+	return "", time.Time{}, nil
+}
@@ -0,0 +1,148 @@
+package lock
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+	"github.com/segmentio/ebs-backup/internal/engine"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTagLock(t *testing.T) {
+	assert := assert.New(t)
+	defer func() { now, sleep = time.Now, time.Sleep }()
+	now = func() time.Time { return time.Unix(1000, 0) }
+	sleep = func(time.Duration) {}
+
+	var lease string
+	var deleted *ec2.DeleteTagsInput
+
+	l := Tag{
+		Owner: "host/1/abc",
+		TTL:   time.Minute,
+		EC2: ec2Mock{
+			DescribeVolumesFunc: func(i *ec2.DescribeVolumesInput) (*ec2.DescribeVolumesOutput, error) {
+				return volume(lease), nil
+			},
+			CreateTagsFunc: func(i *ec2.CreateTagsInput) (*ec2.CreateTagsOutput, error) {
+				lease = *i.Tags[0].Value
+				return nil, nil
+			},
+			DeleteTagsFunc: func(i *ec2.DeleteTagsInput) (*ec2.DeleteTagsOutput, error) {
+				deleted = i
+				return nil, nil
+			},
+		},
+	}
+
+	unlock, err := l.Lock("vol-xyz")
+	assert.NoError(err)
+	assert.Equal("host/1/abc 1060", lease)
+
+	assert.NoError(unlock())
+	assert.Equal("ebs-backup:lock", *deleted.Tags[0].Key)
+	assert.Equal("host/1/abc 1060", *deleted.Tags[0].Value)
+}
+
+func TestTagLocked(t *testing.T) {
+	assert := assert.New(t)
+	defer func() { now = time.Now }()
+	now = func() time.Time { return time.Unix(1000, 0) }
+
+	l := Tag{
+		Owner: "host/1/abc",
+		EC2: ec2Mock{
+			DescribeVolumesFunc: func(i *ec2.DescribeVolumesInput) (*ec2.DescribeVolumesOutput, error) {
+				return volume("host/2/def 1030"), nil
+			},
+		},
+	}
+
+	_, err := l.Lock("vol-xyz")
+	assert.Equal(engine.ErrLocked, err)
+}
+
+func TestTagLockExpired(t *testing.T) {
+	assert := assert.New(t)
+	defer func() { now, sleep = time.Now, time.Sleep }()
+	now = func() time.Time { return time.Unix(1000, 0) }
+	sleep = func(time.Duration) {}
+
+	lease := "host/2/def 900"
+
+	l := Tag{
+		Owner: "host/1/abc",
+		EC2: ec2Mock{
+			DescribeVolumesFunc: func(i *ec2.DescribeVolumesInput) (*ec2.DescribeVolumesOutput, error) {
+				return volume(lease), nil
+			},
+			CreateTagsFunc: func(i *ec2.CreateTagsInput) (*ec2.CreateTagsOutput, error) {
+				lease = *i.Tags[0].Value
+				return nil, nil
+			},
+		},
+	}
+
+	_, err := l.Lock("vol-xyz")
+	assert.NoError(err)
+}
+
+func TestTagLockRace(t *testing.T) {
+	assert := assert.New(t)
+	defer func() { sleep = time.Sleep }()
+	sleep = func(time.Duration) {}
+
+	var lease string
+
+	l := Tag{
+		Owner: "host/1/abc",
+		EC2: ec2Mock{
+			DescribeVolumesFunc: func(i *ec2.DescribeVolumesInput) (*ec2.DescribeVolumesOutput, error) {
+				return volume(lease), nil
+			},
+			CreateTagsFunc: func(i *ec2.CreateTagsInput) (*ec2.CreateTagsOutput, error) {
+				// Another run writes its lease right after ours.
+				lease = "host/2/def 9999999999"
+				return nil, nil
+			},
+		},
+	}
+
+	_, err := l.Lock("vol-xyz")
+	assert.Equal(engine.ErrLocked, err)
+}
+
+func volume(lease string) *ec2.DescribeVolumesOutput {
+	v := &ec2.Volume{VolumeId: aws.String("vol-xyz")}
+
+	if lease != "" {
+		v.Tags = []*ec2.Tag{{
+			Key:   aws.String("ebs-backup:lock"),
+			Value: aws.String(lease),
+		}}
+	}
+
+	return &ec2.DescribeVolumesOutput{Volumes: []*ec2.Volume{v}}
+}
+
+type ec2Mock struct {
+	ec2iface.EC2API
+	DescribeVolumesFunc func(*ec2.DescribeVolumesInput) (*ec2.DescribeVolumesOutput, error)
+	CreateTagsFunc      func(*ec2.CreateTagsInput) (*ec2.CreateTagsOutput, error)
+	DeleteTagsFunc      func(*ec2.DeleteTagsInput) (*ec2.DeleteTagsOutput, error)
+}
+
+func (m ec2Mock) DescribeVolumes(i *ec2.DescribeVolumesInput) (*ec2.DescribeVolumesOutput, error) {
+	return m.DescribeVolumesFunc(i)
+}
+
+func (m ec2Mock) CreateTags(i *ec2.CreateTagsInput) (*ec2.CreateTagsOutput, error) {
+	return m.CreateTagsFunc(i)
+}
+
+func (m ec2Mock) DeleteTags(i *ec2.DeleteTagsInput) (*ec2.DeleteTagsOutput, error) {
+	return m.DeleteTagsFunc(i)
+}
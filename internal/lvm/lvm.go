@@ -0,0 +1,284 @@
+// Package lvm implements engine.Provider for the thin
+// logical volumes of LVM on top of the LVM commands.
+package lvm
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/segmentio/ebs-backup/internal/engine"
+)
+
+// timeLayout is the layout of `lv_time`.
+const timeLayout = "2006-01-02 15:04:05 -0700"
+
+// now returns the current time, it is replaced in tests.
+var now = time.Now
+
+// Runner runs a command and returns its standard output.
+type Runner interface {
+	Run(name string, args ...string) ([]byte, error)
+}
+
+// Exec is the Runner of os/exec.
+type Exec struct{}
+
+// Run implements Runner, the error holds the standard error.
+func (Exec) Run(name string, args ...string) ([]byte, error) {
+	var stderr bytes.Buffer
+
+	cmd := exec.Command(name, args...)
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s: %s", name, err, strings.TrimSpace(stderr.String()))
+	}
+
+	return out, nil
+}
+
+// Provider is an engine.Provider of LVM thin volumes.
+//
+// Volumes are the thin volumes of volume group `VG`, or of
+// all groups if empty, that carry all of the LV `Tags`. Their
+// snapshots are thin snapshots named after the volume and the
+// time, tagged like EBS snapshots, see `tags`. Volume and
+// snapshot ids are `vg/lv` paths. Runner defaults to `Exec`.
+type Provider struct {
+	VG     string
+	Tags   []string
+	Runner Runner
+}
+
+// lv is a logical volume as reported by `lvs`.
+type lv struct {
+	Name   string `json:"lv_name"`
+	VG     string `json:"vg_name"`
+	Attr   string `json:"lv_attr"`
+	Origin string `json:"origin"`
+	Pool   string `json:"pool_lv"`
+	Tags   string `json:"lv_tags"`
+	Time   string `json:"lv_time"`
+}
+
+// ID returns the `vg/lv` path of the volume.
+func (v lv) ID() string {
+	return v.VG + "/" + v.Name
+}
+
+// Volumes implements engine.Provider.
+//
+// The selector's `.Name` is a pattern matched with `path.Match`
+// against LV names, `.VolumeIDs`, if any, are `vg/lv` paths that
+// replace it, devices are ignored. Snapshots are never selected.
+func (p Provider) Volumes(s engine.Selector) ([]engine.Volume, error) {
+	lvs, err := p.lvs(p.VG)
+	if err != nil {
+		return nil, err
+	}
+
+	var ret []engine.Volume
+
+	for _, v := range lvs {
+		if p.selected(v, s) {
+			ret = append(ret, engine.Volume{
+				ID:   v.ID(),
+				Name: v.Name,
+				Tags: tags(v.Tags),
+			})
+		}
+	}
+
+	return ret, nil
+}
+
+// Snapshots implements engine.Provider.
+func (p Provider) Snapshots(id string) ([]engine.Snapshot, error) {
+	vg, name := path.Split(id)
+
+	lvs, err := p.lvs(strings.TrimSuffix(vg, "/"))
+	if err != nil {
+		return nil, err
+	}
+
+	var ret []engine.Snapshot
+
+	for _, v := range lvs {
+		if v.Origin != name {
+			continue
+		}
+
+		created, err := time.Parse(timeLayout, v.Time)
+		if err != nil {
+			return nil, fmt.Errorf("lv_time of %s: %s", v.ID(), err)
+		}
+
+		ret = append(ret, engine.Snapshot{
+			ID:       v.ID(),
+			VolumeID: id,
+			State:    engine.SnapshotCompleted,
+			Created:  created.UTC(),
+			Tags:     tags(v.Tags),
+		})
+	}
+
+	return ret, nil
+}
+
+// CreateSnapshot implements engine.Provider.
+//
+// Thin snapshots are created instantly and skipped on
+// activation, the description is kept as a tag since
+// logical volumes have none.
+func (p Provider) CreateSnapshot(v engine.Volume, description string, tags map[string]string) (engine.Snapshot, error) {
+	t := now()
+	vg, name := path.Split(v.ID)
+	snap := fmt.Sprintf("%s-%s", name, t.UTC().Format("20060102-150405"))
+
+	args := []string{"--snapshot", "--setactivationskip", "y", "--name", snap}
+	for _, tag := range lvTags(tags) {
+		args = append(args, "--addtag", tag)
+	}
+	if description != "" {
+		args = append(args, "--addtag", lvTag(tagDescription, description))
+	}
+	args = append(args, v.ID)
+
+	if _, err := p.run("lvcreate", args...); err != nil {
+		return engine.Snapshot{}, err
+	}
+
+	return engine.Snapshot{
+		ID:       vg + snap,
+		VolumeID: v.ID,
+		State:    engine.SnapshotCompleted,
+		Created:  t,
+		Tags:     tags,
+	}, nil
+}
+
+// TagSnapshot implements engine.Provider, the previous
+// values of the keys of `tags` are removed.
+func (p Provider) TagSnapshot(id string, tags map[string]string) error {
+	lvs, err := p.lvs(id)
+	if err != nil {
+		return err
+	}
+
+	var args []string
+
+	for _, v := range lvs {
+		for _, tag := range split(v.Tags) {
+			k, _ := pair(tag)
+			if _, ok := tags[k]; ok {
+				args = append(args, "--deltag", tag)
+			}
+		}
+	}
+
+	for _, tag := range lvTags(tags) {
+		args = append(args, "--addtag", tag)
+	}
+
+	_, err = p.run("lvchange", append(args, id)...)
+	return err
+}
+
+// DeleteSnapshot implements engine.Provider.
+func (p Provider) DeleteSnapshot(id string) error {
+	_, err := p.run("lvremove", "--yes", id)
+	return err
+}
+
+// CopySnapshot implements engine.Provider, snapshots
+// are local to the host and cannot be copied.
+func (p Provider) CopySnapshot(engine.Snapshot, string, map[string]string) (engine.Snapshot, error) {
+	return engine.Snapshot{}, engine.ErrNotSupported
+}
+
+// selected reports whether `v` is a thin volume selected by `s` and `.Tags`.
+func (p Provider) selected(v lv, s engine.Selector) bool {
+	if !strings.HasPrefix(v.Attr, "V") || v.Pool == "" || v.Origin != "" {
+		return false
+	}
+
+	have := split(v.Tags)
+	for _, want := range p.Tags {
+		if !contains(have, want) {
+			return false
+		}
+	}
+
+	if len(s.VolumeIDs) > 0 {
+		return contains(s.VolumeIDs, v.ID())
+	}
+
+	ok, _ := path.Match(s.Name, v.Name)
+	return s.Name == "" || ok
+}
+
+// lvs returns the logical volumes of `target`, a volume
+// group or `vg/lv` path, or all of them if empty.
+func (p Provider) lvs(target string) ([]lv, error) {
+	args := []string{
+		"--reportformat", "json",
+		"--options", "lv_name,vg_name,lv_attr,origin,pool_lv,lv_tags,lv_time",
+	}
+	if target != "" {
+		args = append(args, target)
+	}
+
+	out, err := p.run("lvs", args...)
+	if err != nil {
+		return nil, err
+	}
+
+	var report struct {
+		Report []struct {
+			LV []lv `json:"lv"`
+		} `json:"report"`
+	}
+
+	if err := json.Unmarshal(out, &report); err != nil {
+		return nil, fmt.Errorf("lvs: %s", err)
+	}
+
+	var ret []lv
+	for _, r := range report.Report {
+		ret = append(ret, r.LV...)
+	}
+
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i].ID() < ret[j].ID()
+	})
+
+	return ret, nil
+}
+
+// run runs command `name` with `.Runner`.
+func (p Provider) run(name string, args ...string) ([]byte, error) {
+	r := p.Runner
+	if r == nil {
+		r = Exec{}
+	}
+
+	return r.Run(name, args...)
+}
+
+// contains reports whether `set` contains `s`.
+func contains(set []string, s string) bool {
+	for _, v := range set {
+		if v == s {
+			return true
+		}
+	}
+
+	return false
+}
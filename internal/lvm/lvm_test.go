@@ -0,0 +1,249 @@
+package lvm
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/segmentio/ebs-backup/internal/engine"
+	"github.com/stretchr/testify/assert"
+)
+
+const options = "lvs --reportformat json --options lv_name,vg_name,lv_attr,origin,pool_lv,lv_tags,lv_time"
+
+type mock struct {
+	RunFunc func(name string, args ...string) ([]byte, error)
+}
+
+func (m mock) Run(name string, args ...string) ([]byte, error) {
+	return m.RunFunc(name, args...)
+}
+
+// report returns the `lvs` output of `list`.
+func report(list ...lv) []byte {
+	b, _ := json.Marshal(map[string]interface{}{
+		"report": []interface{}{map[string]interface{}{"lv": list}},
+	})
+	return b
+}
+
+// thin returns a thin volume of "vg0" created `age` before 1970-01-02.
+func thin(name, origin string, age time.Duration, tags string) lv {
+	attr := "Vwi-aotz--"
+	if origin != "" {
+		attr = "Vwi---tz-k"
+	}
+
+	return lv{
+		Name:   name,
+		VG:     "vg0",
+		Attr:   attr,
+		Origin: origin,
+		Pool:   "pool",
+		Tags:   tags,
+		Time:   time.Unix(86400, 0).UTC().Add(-age).Format(timeLayout),
+	}
+}
+
+func TestVolumes(t *testing.T) {
+	assert := assert.New(t)
+
+	var commands []string
+
+	p := Provider{
+		VG:   "vg0",
+		Tags: []string{"backup"},
+		Runner: mock{
+			RunFunc: func(name string, args ...string) ([]byte, error) {
+				commands = append(commands, name+" "+strings.Join(args, " "))
+				return report(
+					thin("web", "", 0, "backup"),
+					thin("db", "", 0, "backup,ebs-backup:retain=3"),
+					thin("db-tmp", "", 0, "scratch"),
+					thin("db-20200101-000000", "db", time.Hour, "backup"),
+					lv{Name: "root", VG: "vg0", Attr: "-wi-ao----", Tags: "backup"},
+				), nil
+			},
+		},
+	}
+
+	cases := []struct {
+		selector engine.Selector
+		ids      []string
+	}{
+		{engine.Selector{Name: "db*"}, []string{"vg0/db"}},
+		{engine.Selector{}, []string{"vg0/db", "vg0/web"}},
+		{engine.Selector{Name: "db*", VolumeIDs: []string{"vg0/web"}}, []string{"vg0/web"}},
+		{engine.Selector{VolumeIDs: []string{"vg0/db-tmp", "vg0/root"}}, nil},
+	}
+
+	for _, c := range cases {
+		volumes, err := p.Volumes(c.selector)
+		assert.NoError(err)
+
+		var ids []string
+		for _, v := range volumes {
+			ids = append(ids, v.ID)
+		}
+		assert.Equal(c.ids, ids)
+	}
+
+	volumes, err := p.Volumes(engine.Selector{Name: "db"})
+	assert.NoError(err)
+	assert.Equal([]engine.Volume{{
+		ID:   "vg0/db",
+		Name: "db",
+		Tags: map[string]string{"backup": "", "ebs-backup:retain": "3"},
+	}}, volumes)
+	assert.Equal(options+" vg0", commands[0])
+}
+
+func TestSnapshots(t *testing.T) {
+	assert := assert.New(t)
+
+	var commands []string
+
+	p := Provider{
+		Runner: mock{
+			RunFunc: func(name string, args ...string) ([]byte, error) {
+				commands = append(commands, name+" "+strings.Join(args, " "))
+				return report(
+					thin("db", "", 0, "backup"),
+					thin("db-b", "db", time.Hour, "ebs-backup:job=db,team=data"),
+					thin("db-a", "db", 2*time.Hour, "ebs-backup:job=db"),
+					thin("web-a", "web", time.Hour, "ebs-backup:job=web"),
+				), nil
+			},
+		},
+	}
+
+	snapshots, err := p.Snapshots("vg0/db")
+	assert.NoError(err)
+	assert.Equal([]engine.Snapshot{
+		{
+			ID:       "vg0/db-a",
+			VolumeID: "vg0/db",
+			State:    engine.SnapshotCompleted,
+			Created:  time.Unix(86400-2*3600, 0).UTC(),
+			Tags:     map[string]string{"ebs-backup:job": "db"},
+		},
+		{
+			ID:       "vg0/db-b",
+			VolumeID: "vg0/db",
+			State:    engine.SnapshotCompleted,
+			Created:  time.Unix(86400-3600, 0).UTC(),
+			Tags:     map[string]string{"ebs-backup:job": "db", "team": "data"},
+		},
+	}, snapshots)
+	assert.Equal([]string{options + " vg0"}, commands)
+}
+
+func TestCreateSnapshot(t *testing.T) {
+	assert := assert.New(t)
+
+	now = func() time.Time { return time.Unix(86400, 0).UTC() }
+	defer func() { now = time.Now }()
+
+	var command string
+
+	p := Provider{
+		Runner: mock{
+			RunFunc: func(name string, args ...string) ([]byte, error) {
+				command = name + " " + strings.Join(args, " ")
+				return nil, nil
+			},
+		},
+	}
+
+	s, err := p.CreateSnapshot(engine.Volume{ID: "vg0/db"}, "backup of vg0/db", map[string]string{
+		"team":           "data",
+		"ebs-backup:job": "db",
+	})
+	assert.NoError(err)
+	assert.Equal(engine.Snapshot{
+		ID:       "vg0/db-19700102-000000",
+		VolumeID: "vg0/db",
+		State:    engine.SnapshotCompleted,
+		Created:  time.Unix(86400, 0).UTC(),
+		Tags:     map[string]string{"team": "data", "ebs-backup:job": "db"},
+	}, s)
+	assert.Equal("lvcreate --snapshot --setactivationskip y --name db-19700102-000000 "+
+		"--addtag ebs-backup:job=db --addtag team=data "+
+		"--addtag ebs-backup:description=backup_of_vg0/db vg0/db", command)
+
+	assert.NoError(p.DeleteSnapshot("vg0/db-19700102-000000"))
+	assert.Equal("lvremove --yes vg0/db-19700102-000000", command)
+}
+
+func TestTagSnapshot(t *testing.T) {
+	assert := assert.New(t)
+
+	var commands []string
+
+	p := Provider{
+		Runner: mock{
+			RunFunc: func(name string, args ...string) ([]byte, error) {
+				commands = append(commands, name+" "+strings.Join(args, " "))
+				return report(thin("db-a", "db", time.Hour, "team=data,ebs-backup:error=old")), nil
+			},
+		},
+	}
+
+	assert.NoError(p.TagSnapshot("vg0/db-a", map[string]string{"ebs-backup:error": "2020-01-01T00:00:00Z"}))
+	assert.Equal([]string{
+		options + " vg0/db-a",
+		"lvchange --deltag ebs-backup:error=old --addtag ebs-backup:error=2020-01-01T00:00:00Z vg0/db-a",
+	}, commands)
+}
+
+func TestErrors(t *testing.T) {
+	assert := assert.New(t)
+
+	boom := errors.New("lvs: exit status 5: Volume group \"vg0\" not found")
+
+	p := Provider{
+		VG: "vg0",
+		Runner: mock{
+			RunFunc: func(name string, args ...string) ([]byte, error) {
+				return nil, boom
+			},
+		},
+	}
+
+	_, err := p.Volumes(engine.Selector{})
+	assert.Equal(boom, err)
+
+	_, err = p.Snapshots("vg0/db")
+	assert.Equal(boom, err)
+
+	p.Runner = mock{
+		RunFunc: func(name string, args ...string) ([]byte, error) {
+			return report(lv{Name: "db-a", VG: "vg0", Origin: "db", Time: "yesterday"}), nil
+		},
+	}
+
+	_, err = p.Snapshots("vg0/db")
+	assert.EqualError(err, `lv_time of vg0/db-a: parsing time "yesterday" as "2006-01-02 15:04:05 -0700": cannot parse "yesterday" as "2006"`)
+
+	_, err = p.CopySnapshot(engine.Snapshot{}, "us-east-1", nil)
+	assert.Equal(engine.ErrNotSupported, err)
+}
+
+func TestLVTags(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal([]string{
+		"backup",
+		"ebs-backup:job=db",
+		"team_name=Data_Eng",
+	}, lvTags(map[string]string{
+		"team=name":      "Data Eng",
+		"backup":         "",
+		"ebs-backup:job": "db",
+		"":               "x",
+	}))
+
+	assert.Equal(map[string]string{"a": "b=c", "d": ""}, tags("a=b=c,d"))
+}
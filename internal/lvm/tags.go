@@ -0,0 +1,99 @@
+package lvm
+
+import (
+	"sort"
+	"strings"
+)
+
+// LV tags are single strings of at most 1024 letters, digits and
+// `_+.-/=!:&#` characters, so engine tags are stored as `key=value`
+// tags and other characters are replaced. Logical volumes have no
+// description, it is stored as the `ebs-backup:description` tag.
+const (
+	tagDescription = "ebs-backup:description"
+	maxTag         = 1024
+)
+
+// lvTags returns the engine `tags` as sorted LV tags.
+func lvTags(tags map[string]string) []string {
+	ret := make([]string, 0, len(tags))
+
+	for k, v := range tags {
+		if tag := lvTag(k, v); tag != "" {
+			ret = append(ret, tag)
+		}
+	}
+
+	sort.Strings(ret)
+	return ret
+}
+
+// lvTag returns the LV tag of engine tag `k` with value `v`,
+// tags without a value are stored as their key.
+func lvTag(k, v string) string {
+	k = sanitize(k, false)
+	if k == "" {
+		return ""
+	}
+
+	tag := k
+	if v != "" {
+		tag += "=" + sanitize(v, true)
+	}
+	if len(tag) > maxTag {
+		tag = tag[:maxTag]
+	}
+
+	return tag
+}
+
+// tags returns the comma separated `lv_tags` as engine tags.
+func tags(s string) map[string]string {
+	ret := make(map[string]string)
+
+	for _, tag := range split(s) {
+		k, v := pair(tag)
+		ret[k] = v
+	}
+
+	return ret
+}
+
+// split returns the comma separated `lv_tags` as a list.
+func split(s string) []string {
+	var ret []string
+
+	for _, tag := range strings.Split(s, ",") {
+		if tag != "" {
+			ret = append(ret, tag)
+		}
+	}
+
+	return ret
+}
+
+// pair returns the key and value of LV `tag`, the
+// value of tags that are not `key=value` is empty.
+func pair(tag string) (string, string) {
+	if i := strings.Index(tag, "="); i >= 0 {
+		return tag[:i], tag[i+1:]
+	}
+	return tag, ""
+}
+
+// sanitize returns `s` with invalid characters replaced by
+// underscores, `=` is only valid in values.
+func sanitize(s string, value bool) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case 'a' <= r && r <= 'z', 'A' <= r && r <= 'Z', '0' <= r && r <= '9':
+			return r
+		case strings.ContainsRune("_+.-/!:&#", r):
+			return r
+		case r == '=' && value:
+			return r
+		default:
+			return '_'
+		}
+	}, s)
+}
@@ -0,0 +1,174 @@
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Crontab is a schedule parsed from a 5 field cron expression,
+// each field is a bit set of the values it matches.
+type crontab struct {
+	minute, hour, dom, month, dow uint64
+
+	// Standard cron matches either the day of month or the day of
+	// week when both are restricted, and both otherwise.
+	domStar, dowStar bool
+}
+
+// field describes the bounds of a cron field.
+type field struct {
+	name     string
+	min, max int
+}
+
+var (
+	minutes = field{"minute", 0, 59}
+	hours   = field{"hour", 0, 23}
+	doms    = field{"day of month", 1, 31}
+	months  = field{"month", 1, 12}
+	dows    = field{"day of week", 0, 7}
+)
+
+// cron parses a 5 field cron expression.
+func cron(expr string) (Schedule, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("schedule %q: expected 5 fields, got %d", expr, len(fields))
+	}
+
+	var c crontab
+	var err error
+
+	parsers := []struct {
+		dst *uint64
+		f   field
+	}{
+		{&c.minute, minutes},
+		{&c.hour, hours},
+		{&c.dom, doms},
+		{&c.month, months},
+		{&c.dow, dows},
+	}
+
+	for i, p := range parsers {
+		if *p.dst, err = parseField(fields[i], p.f); err != nil {
+			return nil, fmt.Errorf("schedule %q: %s", expr, err)
+		}
+	}
+
+	// Sunday is both 0 and 7.
+	if c.dow&(1<<7) != 0 {
+		c.dow |= 1
+	}
+
+	c.domStar = fields[2] == "*"
+	c.dowStar = fields[4] == "*"
+	return c, nil
+}
+
+// parseField parses a comma separated list of values, ranges
+// and steps, such as `*/15`, `1-5` or `0,30`, into a bit set.
+func parseField(s string, f field) (uint64, error) {
+	var set uint64
+
+	for _, part := range strings.Split(s, ",") {
+		step := 1
+
+		if i := strings.Index(part, "/"); i >= 0 {
+			n, err := strconv.Atoi(part[i+1:])
+			if err != nil || n < 1 {
+				return 0, fmt.Errorf("invalid %s step %q", f.name, part)
+			}
+			step = n
+			part = part[:i]
+		}
+
+		lo, hi := f.min, f.max
+
+		switch {
+		case part == "*":
+		case strings.Contains(part, "-"):
+			bounds := strings.SplitN(part, "-", 2)
+			var err error
+			if lo, err = value(bounds[0], f); err != nil {
+				return 0, err
+			}
+			if hi, err = value(bounds[1], f); err != nil {
+				return 0, err
+			}
+			if lo > hi {
+				return 0, fmt.Errorf("invalid %s range %q", f.name, part)
+			}
+		default:
+			n, err := value(part, f)
+			if err != nil {
+				return 0, err
+			}
+			lo = n
+			if step == 1 {
+				hi = n
+			}
+		}
+
+		for i := lo; i <= hi; i += step {
+			set |= 1 << uint(i)
+		}
+	}
+
+	return set, nil
+}
+
+// value parses a single value of field `f`.
+func value(s string, f field) (int, error) {
+	n, err := strconv.Atoi(s)
+	if err != nil || n < f.min || n > f.max {
+		return 0, fmt.Errorf("invalid %s %q", f.name, s)
+	}
+	return n, nil
+}
+
+// Next implements Schedule.
+//
+// The zero time is returned if the schedule does not
+// activate within the next 5 years, e.g. for `0 0 30 2 *`.
+func (c crontab) Next(t time.Time) time.Time {
+	loc := t.Location()
+	t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute()+1, 0, 0, loc)
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		switch {
+		case !has(c.month, int(t.Month())):
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+		case !c.day(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+		case !has(c.hour, t.Hour()):
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+		case !has(c.minute, t.Minute()):
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+
+	return time.Time{}
+}
+
+// day reports whether the day of `t` matches.
+func (c crontab) day(t time.Time) bool {
+	dom := has(c.dom, t.Day())
+	dow := has(c.dow, int(t.Weekday()))
+
+	if c.domStar || c.dowStar {
+		return dom && dow
+	}
+
+	return dom || dow
+}
+
+// has reports whether `set` contains `n`.
+func has(set uint64, n int) bool {
+	return set&(1<<uint(n)) != 0
+}
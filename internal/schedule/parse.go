@@ -0,0 +1,97 @@
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule returns the next activation time, later than the given time.
+type Schedule interface {
+	Next(time.Time) time.Time
+}
+
+// descriptors are the supported cron shorthands.
+var descriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// Parse parses a schedule expression.
+//
+// The expression is one of:
+//
+//   - A cron expression with 5 fields, `minute hour day-of-month month day-of-week`
+//   - A cron shorthand such as `@daily` or `@hourly`
+//   - `@every <duration>`, for example `@every 90m`
+//   - A CloudWatch rate expression, for example `rate(2 hours)`
+func Parse(expr string) (Schedule, error) {
+	expr = strings.TrimSpace(expr)
+
+	if s, ok := descriptors[expr]; ok {
+		expr = s
+	}
+
+	switch {
+	case strings.HasPrefix(expr, "@every "):
+		d, err := time.ParseDuration(strings.TrimSpace(strings.TrimPrefix(expr, "@every ")))
+		if err != nil {
+			return nil, fmt.Errorf("schedule %q: %s", expr, err)
+		}
+		return every(d)
+	case strings.HasPrefix(expr, "rate(") && strings.HasSuffix(expr, ")"):
+		return rate(expr)
+	default:
+		return cron(expr)
+	}
+}
+
+// Interval is a schedule that activates at a fixed interval.
+type interval time.Duration
+
+// Next implements Schedule.
+func (i interval) Next(t time.Time) time.Time {
+	return t.Add(time.Duration(i))
+}
+
+// every returns an interval schedule for `d`.
+func every(d time.Duration) (Schedule, error) {
+	if d < time.Second {
+		return nil, fmt.Errorf("schedule interval %s is less than a second", d)
+	}
+
+	return interval(d), nil
+}
+
+// units are the units of a rate expression.
+var units = map[string]time.Duration{
+	"minute": time.Minute,
+	"hour":   time.Hour,
+	"day":    24 * time.Hour,
+}
+
+// rate parses a CloudWatch rate expression.
+func rate(expr string) (Schedule, error) {
+	fields := strings.Fields(expr[len("rate(") : len(expr)-1])
+	if len(fields) != 2 {
+		return nil, fmt.Errorf("schedule %q: expected rate(<value> <unit>)", expr)
+	}
+
+	n, err := strconv.Atoi(fields[0])
+	if err != nil || n < 1 {
+		return nil, fmt.Errorf("schedule %q: invalid value %q", expr, fields[0])
+	}
+
+	unit, ok := units[strings.TrimSuffix(fields[1], "s")]
+	if !ok {
+		return nil, fmt.Errorf("schedule %q: invalid unit %q", expr, fields[1])
+	}
+
+	return every(time.Duration(n) * unit)
+}
@@ -0,0 +1,76 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCron(t *testing.T) {
+	assert := assert.New(t)
+
+	// Monday.
+	start := time.Date(2018, 5, 7, 10, 17, 30, 0, time.UTC)
+
+	tests := map[string]time.Time{
+		"* * * * *":      time.Date(2018, 5, 7, 10, 18, 0, 0, time.UTC),
+		"*/15 * * * *":   time.Date(2018, 5, 7, 10, 30, 0, 0, time.UTC),
+		"0 */2 * * *":    time.Date(2018, 5, 7, 12, 0, 0, 0, time.UTC),
+		"30 3 * * *":     time.Date(2018, 5, 8, 3, 30, 0, 0, time.UTC),
+		"0 0 * * 0":      time.Date(2018, 5, 13, 0, 0, 0, 0, time.UTC),
+		"0 0 * * 7":      time.Date(2018, 5, 13, 0, 0, 0, 0, time.UTC),
+		"0 9 * * 1-5":    time.Date(2018, 5, 8, 9, 0, 0, 0, time.UTC),
+		"0 0 1 * *":      time.Date(2018, 6, 1, 0, 0, 0, 0, time.UTC),
+		"0 0 1,15 * *":   time.Date(2018, 5, 15, 0, 0, 0, 0, time.UTC),
+		"0 0 1 1 *":      time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC),
+		"0 0 13 * 5":     time.Date(2018, 5, 11, 0, 0, 0, 0, time.UTC),
+		"@hourly":        time.Date(2018, 5, 7, 11, 0, 0, 0, time.UTC),
+		"@daily":         time.Date(2018, 5, 8, 0, 0, 0, 0, time.UTC),
+		"@every 90m":     start.Add(90 * time.Minute),
+		"rate(2 hours)":  start.Add(2 * time.Hour),
+		"rate(1 minute)": start.Add(time.Minute),
+		"rate(3 days)":   start.Add(72 * time.Hour),
+	}
+
+	for expr, want := range tests {
+		s, err := Parse(expr)
+		if assert.NoError(err, expr) {
+			assert.Equal(want, s.Next(start), expr)
+		}
+	}
+}
+
+func TestParseNever(t *testing.T) {
+	assert := assert.New(t)
+
+	s, err := Parse("0 0 30 2 *")
+	assert.NoError(err)
+	assert.True(s.Next(time.Now()).IsZero())
+}
+
+func TestParseErr(t *testing.T) {
+	assert := assert.New(t)
+
+	exprs := []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"@every nope",
+		"@every 1ms",
+		"rate(2)",
+		"rate(0 hours)",
+		"rate(2 weeks)",
+	}
+
+	for _, expr := range exprs {
+		_, err := Parse(expr)
+		assert.Error(err, expr)
+	}
+}
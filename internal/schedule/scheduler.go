@@ -0,0 +1,174 @@
+package schedule
+
+import (
+	"encoding/json"
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/apex/log"
+)
+
+// Task is a func that is run on a schedule.
+//
+// Each activation is delayed by a random duration of up to
+// Jitter, so that tasks sharing a schedule do not run at once.
+type Task struct {
+	Name     string
+	Schedule Schedule
+	Jitter   time.Duration
+	Run      func() error
+}
+
+// Status describes the state of a task.
+//
+// Skipped counts the activations that were skipped
+// because the previous run had not finished yet.
+type Status struct {
+	Name      string    `json:"Name"`
+	Running   bool      `json:"Running"`
+	Next      time.Time `json:"Next"`
+	LastStart time.Time `json:"LastStart"`
+	LastEnd   time.Time `json:"LastEnd"`
+	LastError string    `json:"LastError"`
+	Runs      int       `json:"Runs"`
+	Skipped   int       `json:"Skipped"`
+}
+
+// Scheduler runs tasks on their schedule, it never
+// runs a task while its previous run is in progress.
+type Scheduler struct {
+	tasks []*task
+	wg    sync.WaitGroup
+}
+
+// task is a scheduled task and its status.
+type task struct {
+	Task
+	mu     sync.Mutex
+	status Status
+}
+
+// New returns a new Scheduler for `tasks`.
+func New(tasks ...Task) *Scheduler {
+	s := &Scheduler{}
+
+	for _, t := range tasks {
+		s.tasks = append(s.tasks, &task{
+			Task:   t,
+			status: Status{Name: t.Name},
+		})
+	}
+
+	return s
+}
+
+// Run runs the tasks until `stop` is closed, it then
+// waits for the tasks that are running to finish.
+func (s *Scheduler) Run(stop <-chan struct{}) {
+	var loops sync.WaitGroup
+
+	for _, t := range s.tasks {
+		loops.Add(1)
+		go func(t *task) {
+			defer loops.Done()
+			s.loop(t, stop)
+		}(t)
+	}
+
+	loops.Wait()
+	s.wg.Wait()
+}
+
+// Loop triggers `t` at each activation of its schedule.
+func (s *Scheduler) loop(t *task, stop <-chan struct{}) {
+	for {
+		next := t.Schedule.Next(time.Now())
+		if next.IsZero() {
+			log.WithField("task", t.Name).Warn("schedule has no next activation")
+			return
+		}
+
+		if t.Jitter > 0 {
+			next = next.Add(time.Duration(rand.Int63n(int64(t.Jitter))))
+		}
+
+		t.mu.Lock()
+		t.status.Next = next
+		t.mu.Unlock()
+
+		timer := time.NewTimer(time.Until(next))
+
+		select {
+		case <-stop:
+			timer.Stop()
+			return
+		case <-timer.C:
+			s.trigger(t)
+		}
+	}
+}
+
+// Trigger starts a run of `t` unless it is already running,
+// it reports whether the run was started.
+func (s *Scheduler) trigger(t *task) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	ctx := log.WithField("task", t.Name)
+
+	if t.status.Running {
+		t.status.Skipped++
+		ctx.Warn("previous run in progress, skipping")
+		return false
+	}
+
+	t.status.Running = true
+	t.status.LastStart = time.Now()
+	s.wg.Add(1)
+
+	go func() {
+		defer s.wg.Done()
+
+		ctx.Info("run")
+		err := t.Run()
+
+		t.mu.Lock()
+		defer t.mu.Unlock()
+
+		t.status.Running = false
+		t.status.LastEnd = time.Now()
+		t.status.LastError = ""
+		t.status.Runs++
+
+		if err != nil {
+			t.status.LastError = err.Error()
+			ctx.WithError(err).Error("run")
+			return
+		}
+
+		ctx.WithField("duration", t.status.LastEnd.Sub(t.status.LastStart)).Info("done")
+	}()
+
+	return true
+}
+
+// Status returns the status of all tasks.
+func (s *Scheduler) Status() []Status {
+	ret := make([]Status, 0, len(s.tasks))
+
+	for _, t := range s.tasks {
+		t.mu.Lock()
+		ret = append(ret, t.status)
+		t.mu.Unlock()
+	}
+
+	return ret
+}
+
+// ServeHTTP responds with the status of all tasks as JSON.
+func (s *Scheduler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.Status())
+}
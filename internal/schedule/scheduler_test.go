@@ -0,0 +1,84 @@
+package schedule
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTriggerOverlap(t *testing.T) {
+	assert := assert.New(t)
+
+	release := make(chan struct{})
+
+	s := New(Task{
+		Name: "db",
+		Run: func() error {
+			<-release
+			return errors.New("boom")
+		},
+	})
+
+	assert.True(s.trigger(s.tasks[0]))
+	assert.False(s.trigger(s.tasks[0]))
+
+	status := s.Status()[0]
+	assert.True(status.Running)
+	assert.Equal(1, status.Skipped)
+
+	close(release)
+	s.wg.Wait()
+
+	status = s.Status()[0]
+	assert.False(status.Running)
+	assert.Equal(1, status.Runs)
+	assert.Equal("boom", status.LastError)
+	assert.True(s.trigger(s.tasks[0]))
+	s.wg.Wait()
+}
+
+func TestRun(t *testing.T) {
+	assert := assert.New(t)
+
+	runs := make(chan struct{}, 10)
+	stop := make(chan struct{})
+	done := make(chan struct{})
+
+	s := New(Task{
+		Name:     "db",
+		Schedule: interval(time.Millisecond),
+		Run: func() error {
+			runs <- struct{}{}
+			return nil
+		},
+	})
+
+	go func() {
+		s.Run(stop)
+		close(done)
+	}()
+
+	<-runs
+	close(stop)
+	<-done
+
+	assert.True(s.Status()[0].Runs >= 1)
+}
+
+func TestServeHTTP(t *testing.T) {
+	assert := assert.New(t)
+
+	s := New(Task{Name: "db"})
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest("GET", "/health", nil))
+
+	var status []Status
+	assert.NoError(json.Unmarshal(w.Body.Bytes(), &status))
+	assert.Equal(1, len(status))
+	assert.Equal("db", status[0].Name)
+	assert.Equal("application/json", w.Header().Get("Content-Type"))
+}
@@ -1,32 +1,87 @@
 package main
 
 import (
+	"errors"
 	"flag"
+	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/apex/log"
 	"github.com/apex/log/handlers/cli"
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
 	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+	"github.com/aws/aws-sdk-go/service/recyclebin"
+	"github.com/aws/aws-sdk-go/service/recyclebin/recyclebiniface"
 	"github.com/segmentio/ebs-backup/internal/engine"
+	"github.com/segmentio/ebs-backup/internal/gce"
+	"github.com/segmentio/ebs-backup/internal/lock"
+	"github.com/segmentio/ebs-backup/internal/lvm"
 )
 
 var (
-	version  = "v0.0.0"
-	name     = flag.String("name", "", "name tags that identify the volumes")
-	devices  = flag.String("devices", "", "comma separated list of device names")
-	limit    = flag.Int("limit", 5, "maximum number of snapshots to keep per volume")
-	copyTags = flag.Bool("copy-tags", true, "copy volume tags to the snapshot")
+	version    = "v0.0.0"
+	name       = flag.String("name", "", "name tags that identify the volumes")
+	devices    = flag.String("devices", "", "comma separated list of device names")
+	limit      = flag.Int("limit", 5, "maximum number of snapshots to keep per volume")
+	interval   = flag.Duration("interval", 0, "minimum time between snapshots of a volume")
+	copyTags   = flag.Bool("copy-tags", true, "copy volume tags to the snapshot")
+	tagInclude = flag.String("tag-include", "", "comma separated list of volume tag keys to copy, patterns allowed")
+	tagExclude = flag.String("tag-exclude", "", "comma separated list of volume tag keys not to copy, patterns allowed")
+	tagRename  = flag.String("tag-rename", "", "comma separated list of from=to volume tag key renames")
+	tags       = flag.String("tags", "", "comma separated list of key=template tags to add to the snapshot")
+	job        = flag.String("job", "", "name of the backup job, defaults to --name")
+	desc       = flag.String("description", engine.DefaultDescription, "snapshot description template")
+	lockTable  = flag.String("lock-table", "", "DynamoDB table used to lock volumes while they are backed up")
+	lockTags   = flag.Bool("lock-tags", false, "lock volumes with a lease tag while they are backed up")
+	lockTTL    = flag.Duration("lock-ttl", lock.DefaultTTL, "duration after which a lock that was not released expires")
+	pendWait   = flag.Duration("pending-wait", 0, "maximum time to wait for a pending snapshot of a volume to complete")
+	pendMaxAge = flag.Duration("pending-max-age", 0, "back up volumes even if they have a pending snapshot older than this")
+	errSnaps   = flag.String("error-snapshots", "keep", "what to do with snapshots in the error state: keep, delete or tag")
+	archAfter  = flag.Duration("archive-after", 0, "move snapshots older than this to the archive tier")
+	archExpiry = flag.Duration("archive-expiry", 0, "delete archived snapshots older than this")
+	fastZones  = flag.String("fast-restore-zones", "", "comma separated list of zones to enable fast snapshot restore in for the newest snapshot")
+	images     = flag.Bool("images", false, "back up the instances of the matched volumes with AMIs instead of the volumes")
+	noReboot   = flag.Bool("no-reboot", false, "create AMIs without rebooting the instances")
+	dryRun     = flag.Bool("dry-run", false, "report what would be done without creating or deleting snapshots")
+	backend    = flag.String("provider", "ec2", "block storage provider of the volumes: ec2, gce or lvm")
+	gceProject = flag.String("gce-project", "", "project of the GCE persistent disks")
+	gceZone    = flag.String("gce-zone", "", "zone of the GCE persistent disks")
+	gceLabels  = flag.String("gce-labels", "", "comma separated list of key=value labels that select the GCE disks")
+	gceAPI     = flag.String("gce-endpoint", gce.DefaultEndpoint, "base URL of the GCE Compute API")
+	lvmGroup   = flag.String("lvm-vg", "", "volume group of the LVM thin volumes, all groups if empty")
+	lvmTags    = flag.String("lvm-tags", "", "comma separated list of LV tags that select the LVM thin volumes")
 )
 
+// commands are the subcommands of the program, without
+// a subcommand the program backs up the volumes once.
+var commands = map[string]func(args []string) int{
+	"check":    check,
+	"cost":     cost,
+	"daemon":   daemon,
+	"hold":     hold,
+	"reap":     reap,
+	"release":  release,
+	"report":   report,
+	"undelete": undelete,
+}
+
 func init() {
 	log.SetHandler(cli.Default)
 	log.SetLevel(log.InfoLevel)
 }
 
 func main() {
+	if len(os.Args) > 1 {
+		if cmd, ok := commands[os.Args[1]]; ok {
+			os.Exit(cmd(os.Args[2:]))
+		}
+	}
+
 	flag.Parse()
 
 	if *limit > 1000 || *limit <= 1 {
@@ -34,19 +89,95 @@ func main() {
 	}
 
 	if *name == "" {
-		log.Fatal("--name must be the volume .Name tag")
+		switch *backend {
+		case "gce":
+			log.Fatal("--name must be a pattern of the disk names")
+		case "lvm":
+			log.Fatal("--name must be a pattern of the logical volume names")
+		default:
+			log.Fatal("--name must be the volume .Name tag")
+		}
 	}
 
-	if *devices == "" {
+	if *devices == "" && *backend == "ec2" {
 		log.Fatal("--devices is required")
 	}
 
+	if *lockTags && *backend != "ec2" {
+		log.Fatal("--lock-tags requires the ec2 provider")
+	}
+
+	if *lockTable != "" && *backend != "ec2" {
+		log.Fatal("--lock-table requires the ec2 provider")
+	}
+
+	if *images && *backend != "ec2" {
+		log.Fatal("--images requires the ec2 provider")
+	}
+
+	if (*lockTable != "" || *lockTags) && *pendWait >= *lockTTL {
+		log.Fatal("--pending-wait must be less than --lock-ttl")
+	}
+
+	prov, err := provider(*backend)
+	if err != nil {
+		log.WithError(err).Fatal("--provider")
+	}
+
+	rename, err := pairs(*tagRename)
+	if err != nil {
+		log.WithError(err).Fatal("--tag-rename must be a list of from=to pairs")
+	}
+
+	extra, err := pairs(*tags)
+	if err != nil {
+		log.WithError(err).Fatal("--tags must be a list of key=template pairs")
+	}
+
+	errPolicy, err := engine.ParseErrorPolicy(*errSnaps)
+	if err != nil {
+		log.WithError(err).Fatal("--error-snapshots must be keep, delete or tag")
+	}
+
+	sess := session.New(aws.NewConfig())
+
+	// The Recycle Bin only retains EBS snapshots.
+	var rbin recyclebiniface.RecycleBinAPI
+	if *backend == "ec2" {
+		rbin = recyclebin.New(sess)
+	}
+
 	e := engine.New(engine.Config{
-		EC2:      ec2.New(session.New(aws.NewConfig())),
-		Name:     *name,
-		Limit:    *limit,
-		Devices:  split(*devices),
-		CopyTags: *copyTags,
+		EC2:       ec2.New(sess),
+		EC2Region: regional(sess),
+		Rbin:      rbin,
+		Provider:  prov,
+		Region:    aws.StringValue(sess.Config.Region),
+		Name:      *name,
+		Limit:     *limit,
+		Interval:  *interval,
+		Devices:   split(*devices),
+		CopyTags:  *copyTags,
+		Tags:      extra,
+		TagRules: engine.TagRules{
+			Include: list(*tagInclude),
+			Exclude: list(*tagExclude),
+			Rename:  rename,
+		},
+		Job:              *job,
+		Description:      *desc,
+		Version:          version,
+		Locker:           locker(sess, *lockTable, *lockTags, *lockTTL),
+		LockTTL:          *lockTTL,
+		PendingWait:      *pendWait,
+		PendingMaxAge:    *pendMaxAge,
+		ErrorSnapshots:   errPolicy,
+		ArchiveAfter:     *archAfter,
+		ArchiveExpiry:    *archExpiry,
+		FastRestoreZones: list(*fastZones),
+		Images:           *images,
+		NoReboot:         *noReboot,
+		DryRun:           *dryRun,
 	})
 
 	results, err := e.Run()
@@ -58,15 +189,43 @@ func main() {
 
 	for _, res := range results {
 		ctx := log.WithFields(log.Fields{
-			"volume":      res.VolumeID,
-			"created":     res.CreatedSnapshot,
-			"deleted":     res.DeletedSnapshots,
-			"copied_tags": res.CopiedTags,
+			"volume":       res.VolumeID,
+			"instance":     res.InstanceID,
+			"image":        res.CreatedImage,
+			"created":      res.CreatedSnapshot,
+			"deleted":      res.DeletedSnapshots,
+			"skipped":      res.Skipped,
+			"pending":      res.PendingSnapshot,
+			"errored":      res.ErroredSnapshots,
+			"archived":     res.ArchivedSnapshots,
+			"held":         res.HeldSnapshots,
+			"protected":    res.ProtectedSnapshots,
+			"fast_restore": res.FastRestoreSnapshot,
+			"recycle_bin":  res.RecycleBinRule,
+			"copied_tags":  res.CopiedTags,
+			"dry_run":      *dryRun,
 		})
 
+		if res.DRRegion != "" {
+			ctx = ctx.WithFields(log.Fields{
+				"dr_region":      res.DRRegion,
+				"copied":         res.CopiedSnapshot,
+				"deleted_copies": res.DeletedCopies,
+			})
+		}
+
+		if failed := engine.FailedDeletions(res.Err); failed != nil {
+			ctx = ctx.WithField("not_deleted", failed)
+		}
+
 		if res.Err != nil {
-			ctx.WithError(res.Err).Error("backup")
-			code = 1
+			ctx.WithFields(log.Fields{
+				"phase": engine.ErrorPhase(res.Err),
+				"code":  engine.ErrorCode(res.Err),
+			}).WithError(res.Err).Error("backup")
+			if c := exitCode(res.Err); c > code {
+				code = c
+			}
 			continue
 		}
 
@@ -76,6 +235,114 @@ func main() {
 	os.Exit(code)
 }
 
+// Exit codes of a run that failed, when volumes fail for
+// different reasons the highest code is used. The flag
+// package exits with 2 on usage errors.
+const (
+	exitFailed           = 1
+	exitPending          = 3
+	exitThrottled        = 4
+	exitVolumeGone       = 5
+	exitTagCopy          = 6
+	exitPrune            = 7
+	exitPermissionDenied = 8
+)
+
+// exitCode returns the exit code of a volume that failed with `err`.
+func exitCode(err error) int {
+	switch {
+	case errors.Is(err, engine.ErrPermissionDenied):
+		return exitPermissionDenied
+	case errors.Is(err, engine.ErrPrune):
+		return exitPrune
+	case errors.Is(err, engine.ErrTagCopy):
+		return exitTagCopy
+	case errors.Is(err, engine.ErrVolumeGone):
+		return exitVolumeGone
+	case errors.Is(err, engine.ErrThrottled):
+		return exitThrottled
+	case errors.Is(err, engine.ErrPending):
+		return exitPending
+	default:
+		return exitFailed
+	}
+}
+
+// provider returns the engine provider `name`, nil for ec2
+// since the engine backs up EBS volumes by default.
+//
+// The GCE access token is read from `$GCE_ACCESS_TOKEN`,
+// or the metadata server when running on GCE.
+func provider(name string) (engine.Provider, error) {
+	switch name {
+	case "ec2":
+		return nil, nil
+	case "gce":
+		if *gceProject == "" || *gceZone == "" {
+			return nil, fmt.Errorf("--gce-project and --gce-zone are required")
+		}
+
+		labels, err := pairs(*gceLabels)
+		if err != nil {
+			return nil, fmt.Errorf("--gce-labels: %s", err)
+		}
+
+		token := gce.MetadataToken(nil, gce.DefaultMetadataURL)
+		if s := os.Getenv("GCE_ACCESS_TOKEN"); s != "" {
+			token = gce.StaticToken(s)
+		}
+
+		return gce.Provider{
+			Project:  *gceProject,
+			Zone:     *gceZone,
+			Labels:   labels,
+			Endpoint: *gceAPI,
+			Token:    token,
+		}, nil
+	case "lvm":
+		if *lvmTags == "" {
+			return nil, fmt.Errorf("--lvm-tags is required")
+		}
+
+		return lvm.Provider{
+			VG:   *lvmGroup,
+			Tags: list(*lvmTags),
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown provider %q", name)
+	}
+}
+
+// regional returns a func that returns an EC2 client for a region.
+func regional(sess *session.Session) func(string) ec2iface.EC2API {
+	return func(region string) ec2iface.EC2API {
+		return ec2.New(sess, aws.NewConfig().WithRegion(region))
+	}
+}
+
+// locker returns the volume locker for the given settings, the
+// DynamoDB `table` takes precedence over lease `tags`.
+func locker(sess *session.Session, table string, tags bool, ttl time.Duration) engine.Locker {
+	switch {
+	case table != "":
+		return lock.DynamoDB{
+			API:   dynamodb.New(sess),
+			Table: table,
+			Owner: lock.Owner(),
+			TTL:   ttl,
+		}
+	case tags:
+		return lock.Tag{
+			EC2:    ec2.New(sess),
+			Owner:  lock.Owner(),
+			TTL:    ttl,
+			Settle: 2 * time.Second,
+		}
+	default:
+		return nil
+	}
+}
+
 func split(s string) []string {
 	var ret []string
 
@@ -85,3 +352,27 @@ func split(s string) []string {
 
 	return ret
 }
+
+// list is like split, but returns nil for an empty `s`.
+func list(s string) []string {
+	if strings.TrimSpace(s) == "" {
+		return nil
+	}
+
+	return split(s)
+}
+
+// pairs parses a comma separated list of key=value pairs.
+func pairs(s string) (map[string]string, error) {
+	ret := make(map[string]string)
+
+	for _, pair := range list(s) {
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("invalid pair %q", pair)
+		}
+		ret[parts[0]] = parts[1]
+	}
+
+	return ret, nil
+}
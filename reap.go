@@ -0,0 +1,65 @@
+package main
+
+import (
+	"flag"
+
+	"github.com/apex/log"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/segmentio/ebs-backup/internal/engine"
+)
+
+// reap deletes the managed snapshots of deleted volumes
+// with the orphan retention policy.
+func reap(args []string) int {
+	fs := flag.NewFlagSet("reap", flag.ExitOnError)
+	job := fs.String("job", "", "only reap the snapshots of this backup job")
+	keep := fs.Int("keep", 1, "number of snapshots to keep per deleted volume")
+	maxAge := fs.Duration("max-age", 0, "delete kept snapshots older than this, zero keeps them forever")
+	dryRun := fs.Bool("dry-run", false, "report what would be deleted without deleting snapshots")
+	fs.Parse(args)
+
+	if *keep < 0 {
+		log.Fatal("--keep must not be negative")
+	}
+
+	e := engine.New(engine.Config{
+		EC2:          ec2.New(session.New(aws.NewConfig())),
+		Job:          *job,
+		OrphanKeep:   *keep,
+		OrphanMaxAge: *maxAge,
+		DryRun:       *dryRun,
+	})
+
+	results, err := e.Reap()
+	if err != nil {
+		log.WithError(err).Fatal("error")
+	}
+
+	var code int
+
+	for _, res := range results {
+		ctx := log.WithFields(log.Fields{
+			"volume":  res.VolumeID,
+			"deleted": res.DeletedSnapshots,
+			"dry_run": *dryRun,
+		})
+
+		if failed := engine.FailedDeletions(res.Err); failed != nil {
+			ctx = ctx.WithField("not_deleted", failed)
+		}
+
+		if res.Err != nil {
+			ctx.WithError(res.Err).Error("reap")
+			if c := exitCode(res.Err); c > code {
+				code = c
+			}
+			continue
+		}
+
+		ctx.Info("reap")
+	}
+
+	return code
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/segmentio/ebs-backup/internal/engine"
+	"github.com/segmentio/ebs-backup/internal/handler"
+)
+
+// formats are the output formats of the report.
+var formats = map[string]func(io.Writer, []handler.Coverage) error{
+	"table": table,
+	"json":  jsonReport,
+	"csv":   csvReport,
+}
+
+// report prints the backup coverage of all the volumes of the account.
+func report(args []string) int {
+	fs := flag.NewFlagSet("report", flag.ExitOnError)
+	rpo := fs.Duration("rpo", 24*time.Hour, "volumes whose newest snapshot is older than this are stale")
+	limit := fs.Int("limit", 5, "expected number of snapshots per volume")
+	format := fs.String("format", "table", "output format: table, json or csv")
+	fs.Parse(args)
+
+	write, ok := formats[*format]
+	if !ok {
+		log.Fatal("--format must be table, json or csv")
+	}
+
+	e := engine.New(engine.Config{
+		EC2:   ec2.New(session.New(aws.NewConfig())),
+		Limit: *limit,
+		RPO:   *rpo,
+	})
+
+	results, err := e.Report()
+	if err != nil {
+		log.WithError(err).Fatal("error")
+	}
+
+	rows := make([]handler.Coverage, 0, len(results))
+
+	for _, c := range results {
+		rows = append(rows, handler.NewCoverage(c))
+	}
+
+	if err := write(os.Stdout, rows); err != nil {
+		log.WithError(err).Fatal("write")
+	}
+
+	return 0
+}
+
+// table writes the report as an aligned table.
+func table(w io.Writer, rows []handler.Coverage) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "VOLUME\tNAME\tSTATE\tSTATUS\tSNAPSHOTS\tNEWEST\tAGE\tERROR")
+
+	for _, r := range rows {
+		age := "-"
+		if r.Newest != "" {
+			age = (time.Duration(r.AgeMS) * time.Millisecond).Truncate(time.Minute).String()
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%d/%d\t%s\t%s\t%s\n",
+			r.VolumeID, r.Name, r.State, r.Status, r.Snapshots, r.Limit, r.Newest, age, r.Error)
+	}
+
+	return tw.Flush()
+}
+
+// jsonReport writes the report as a JSON array.
+func jsonReport(w io.Writer, rows []handler.Coverage) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(rows)
+}
+
+// csvReport writes the report as CSV with a header.
+func csvReport(w io.Writer, rows []handler.Coverage) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"VolumeID", "Name", "State", "Status", "Snapshots", "Limit", "Newest", "AgeMS", "Error"})
+
+	for _, r := range rows {
+		cw.Write([]string{
+			r.VolumeID,
+			r.Name,
+			r.State,
+			r.Status,
+			strconv.Itoa(r.Snapshots),
+			strconv.Itoa(r.Limit),
+			r.Newest,
+			strconv.FormatInt(r.AgeMS, 10),
+			r.Error,
+		})
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
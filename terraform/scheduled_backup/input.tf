@@ -27,6 +27,104 @@ variable "copy_tags" {
   description = "Copy tags from EBS volume to snapshot"
 }
 
+variable "tag_include" {
+  type        = list(string)
+  description = "Volume tag keys to copy to snapshots, patterns allowed (all when empty)"
+  default     = []
+}
+
+variable "tag_exclude" {
+  type        = list(string)
+  description = "Volume tag keys not to copy to snapshots, patterns allowed"
+  default     = []
+}
+
+variable "tag_rename" {
+  type        = map(string)
+  description = "Map of volume tag keys to the snapshot tag keys they are copied to"
+  default     = {}
+}
+
+variable "job_name" {
+  type        = string
+  description = "Name of the backup job, set as the `ebs-backup:job` snapshot tag (defaults to `volume_name`)"
+  default     = ""
+}
+
+variable "snapshot_description" {
+  type        = string
+  description = "Snapshot description template (see the Readme for available fields)"
+  default     = ""
+}
+
+variable "snapshot_tags" {
+  type        = map(string)
+  description = "Map of tag keys to value templates added to snapshots"
+  default     = {}
+}
+
+variable "lock_table" {
+  type        = string
+  description = "DynamoDB table, with a `VolumeID` string hash key, used to lock volumes during a backup"
+  default     = ""
+}
+
+variable "lock_tags" {
+  default     = false
+  description = "Lock volumes during a backup with a lease tag, ignored when `lock_table` is set"
+}
+
+variable "pending_wait" {
+  type        = string
+  description = "Maximum time to wait for a pending snapshot to complete (e.g. `2m`), must be less than `timeout`"
+  default     = ""
+}
+
+variable "pending_max_age" {
+  type        = string
+  description = "Back up volumes even if they have a pending snapshot older than this (e.g. `12h`)"
+  default     = ""
+}
+
+variable "error_snapshots" {
+  type        = string
+  description = "What to do with snapshots in the error state: `keep`, `delete` or `tag`"
+  default     = "keep"
+}
+
+variable "archive_after" {
+  type        = string
+  description = "Move snapshots older than this to the archive tier (e.g. `720h`)"
+  default     = ""
+}
+
+variable "archive_expiry" {
+  type        = string
+  description = "Delete archived snapshots older than this, never before 90 days in the archive tier (e.g. `8760h`)"
+  default     = ""
+}
+
+variable "fast_restore_zones" {
+  type        = list(string)
+  description = "Availability zones to enable Fast Snapshot Restore in for the newest snapshot of each volume"
+  default     = []
+}
+
+variable "images" {
+  default     = false
+  description = "Back up the instances of the matched volumes with AMIs instead of the volumes"
+}
+
+variable "no_reboot" {
+  default     = false
+  description = "Create AMIs without rebooting the instances"
+}
+
+variable "fail_on_error" {
+  default     = false
+  description = "Fail the invocation when a volume fails, Lambda then drops the response"
+}
+
 variable "frequency" {
   type        = string
   description = "Frequency at which backup is run (see https://docs.aws.amazon.com/AmazonCloudWatch/latest/events/ScheduledEvents.html#RateExpressions for legal values)"
@@ -44,6 +142,12 @@ variable "snapshot_limit" {
   description = "Number of most recent snapshots to retain"
 }
 
+variable "snapshot_interval" {
+  type        = string
+  description = "Minimum time between snapshots of a volume (e.g. `6h`), volumes snapshotted more recently are skipped"
+  default     = ""
+}
+
 variable "volume_name" {
   type        = string
   description = "Value of `Name` tag on EBS volumes to match"
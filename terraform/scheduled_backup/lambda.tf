@@ -29,10 +29,28 @@ resource "aws_lambda_function" "ebs_backup" {
 
   environment {
     variables = {
-      COPY_TAGS      = var.copy_tags
-      SNAPSHOT_LIMIT = var.snapshot_limit
-      VOLUME_DEVICES = join(" ", var.device_names)
-      VOLUME_NAME    = var.volume_name
+      COPY_TAGS            = var.copy_tags
+      SNAPSHOT_LIMIT       = var.snapshot_limit
+      SNAPSHOT_INTERVAL    = var.snapshot_interval
+      TAG_INCLUDE          = join(",", var.tag_include)
+      TAG_EXCLUDE          = join(",", var.tag_exclude)
+      TAG_RENAME           = join(",", [for k, v in var.tag_rename : "${k}=${v}"])
+      JOB_NAME             = var.job_name
+      SNAPSHOT_DESCRIPTION = var.snapshot_description
+      SNAPSHOT_TAGS        = join(",", [for k, v in var.snapshot_tags : "${k}=${v}"])
+      LOCK_TABLE           = var.lock_table
+      LOCK_TAGS            = var.lock_tags
+      PENDING_WAIT         = var.pending_wait
+      PENDING_MAX_AGE      = var.pending_max_age
+      ERROR_SNAPSHOTS      = var.error_snapshots
+      ARCHIVE_AFTER        = var.archive_after
+      ARCHIVE_EXPIRY       = var.archive_expiry
+      FAST_RESTORE_ZONES   = join(",", var.fast_restore_zones)
+      IMAGES               = var.images
+      NO_REBOOT            = var.no_reboot
+      FAIL_ON_ERROR        = var.fail_on_error
+      VOLUME_DEVICES       = join(" ", var.device_names)
+      VOLUME_NAME          = var.volume_name
     }
   }
 }
@@ -79,7 +97,18 @@ resource "aws_iam_role_policy" "ebs_backup" {
                 "ec2:DescribeVolumes",
                 "ec2:DescribeSnapshots",
                 "ec2:CreateSnapshot",
+                "ec2:CopySnapshot",
                 "ec2:CreateTags",
+                "ec2:DeleteTags",
+                "ec2:ModifySnapshotTier",
+                "ec2:EnableFastSnapshotRestores",
+                "ec2:DisableFastSnapshotRestores",
+                "ec2:CreateImage",
+                "ec2:DescribeImages",
+                "ec2:DeregisterImage",
+                "ec2:DescribeLaunchTemplateVersions",
+                "rbin:ListRules",
+                "rbin:GetRule",
                 "ec2:DeleteSnapshot"
             ],
             "Resource": "*"
@@ -99,3 +128,31 @@ POLICY
 
 }
 
+
+data "aws_dynamodb_table" "lock" {
+  count = var.lock_table != "" ? 1 : 0
+  name  = var.lock_table
+}
+
+resource "aws_iam_role_policy" "ebs_backup_lock" {
+  count = var.lock_table != "" ? 1 : 0
+  name  = "ebs_backup_lock"
+  role  = aws_iam_role.ebs_backup.name
+
+  policy = <<POLICY
+{
+    "Version": "2012-10-17",
+    "Statement": [
+        {
+            "Effect": "Allow",
+            "Action": [
+                "dynamodb:PutItem",
+                "dynamodb:DeleteItem"
+            ],
+            "Resource": "${join("", data.aws_dynamodb_table.lock.*.arn)}"
+        }
+    ]
+}
+POLICY
+
+}
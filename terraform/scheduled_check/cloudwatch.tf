@@ -0,0 +1,34 @@
+resource "aws_cloudwatch_event_rule" "ebs_check" {
+  # Do not use a name_prefix here or the rule will be created twice.
+  # See https://github.com/terraform-providers/terraform-provider-aws/issues/4547
+  name = "ebs-check-${var.volume_name}"
+
+  description         = "Check the backups of ${var.volume_name} every ${var.frequency}"
+  schedule_expression = "rate(${var.frequency})"
+  is_enabled          = var.enable_event_rule
+}
+
+resource "aws_cloudwatch_event_target" "ebs_check" {
+  rule = aws_cloudwatch_event_rule.ebs_check.name
+  arn  = aws_lambda_function.ebs_check.arn
+}
+
+# The function fails when the check is not OK.
+resource "aws_cloudwatch_metric_alarm" "ebs_check" {
+  alarm_name          = format("%.255s", "${local.function_name}-failed")
+  alarm_description   = "Backups of ${var.volume_name} are out of compliance with an RPO of ${var.rpo}"
+  namespace           = "AWS/Lambda"
+  metric_name         = "Errors"
+  statistic           = "Sum"
+  comparison_operator = "GreaterThanOrEqualToThreshold"
+  threshold           = 1
+  period              = 300
+  evaluation_periods  = 1
+  treat_missing_data  = "notBreaching"
+  alarm_actions       = var.alarm_actions
+  ok_actions          = var.alarm_actions
+
+  dimensions = {
+    FunctionName = aws_lambda_function.ebs_check.function_name
+  }
+}
@@ -0,0 +1,66 @@
+variable "lambda_s3_bucket_ssm_parameter" {
+  type        = string
+  default     = "/segment/ebs_backup/lambda_s3_bucket"
+  description = "SSM parameter under which name of Lambda S3 bucket will be found"
+}
+
+variable "lambda_s3_key_ssm_parameter" {
+  type        = string
+  default     = "/segment/ebs_backup/lambda_s3_key"
+  description = "SSM parameter under which name of Lambda S3 key will be found"
+}
+
+variable "lambda_s3_bucket" {
+  type        = string
+  description = "S3 bucket containing EBS backup Lambda function.  If specified, will override any value found in the Parameter Store."
+  default     = ""
+}
+
+variable "lambda_s3_key" {
+  type        = string
+  description = "S3 key pointing to EBS backup Lambda function.  If specified, will override any value found in the Parameter Store."
+  default     = ""
+}
+
+variable "rpo" {
+  type        = string
+  description = "Maximum age of the newest snapshot of each volume (e.g. `6h`)"
+}
+
+variable "snapshot_limit" {
+  default     = 0
+  description = "Number of snapshots each volume is expected to have, volumes with fewer are a warning (not checked when 0)"
+}
+
+variable "frequency" {
+  type        = string
+  description = "Frequency at which the check is run (see https://docs.aws.amazon.com/AmazonCloudWatch/latest/events/ScheduledEvents.html#RateExpressions for legal values)"
+  default     = "1 hour"
+}
+
+variable "timeout" {
+  type        = string
+  description = "Maximum runtime for check Lambda function, in seconds"
+  default     = 60
+}
+
+variable "volume_name" {
+  type        = string
+  description = "Value of `Name` tag on EBS volumes to match"
+}
+
+variable "device_names" {
+  type        = list(string)
+  description = "List of device attachment names to match (e.g. `/dev/xvdf`)"
+}
+
+variable "alarm_actions" {
+  type        = list(string)
+  description = "ARNs notified when the check fails and when it recovers, e.g. SNS topics"
+  default     = []
+}
+
+variable "enable_event_rule" {
+  default     = true
+  description = "Enable event rule (not normally disabled)"
+}
@@ -0,0 +1,97 @@
+data "aws_ssm_parameter" "lambda_s3_bucket" {
+  count = var.lambda_s3_bucket != "" || var.lambda_s3_bucket_ssm_parameter == "" ? 0 : 1
+  name  = var.lambda_s3_bucket_ssm_parameter
+}
+
+data "aws_ssm_parameter" "lambda_s3_key" {
+  count = var.lambda_s3_key != "" || var.lambda_s3_key_ssm_parameter == "" ? 0 : 1
+  name  = var.lambda_s3_key_ssm_parameter
+}
+
+locals {
+  function_name = "ebs-check-${var.volume_name}-${replace(join("-", var.device_names), "/\\/dev\\//", "")}"
+}
+
+resource "aws_lambda_function" "ebs_check" {
+  function_name = format("%.64s", local.function_name)
+  handler       = "ebs-check-lambda"
+  role          = aws_iam_role.ebs_check.arn
+  s3_bucket = coalesce(
+    var.lambda_s3_bucket,
+    join("", data.aws_ssm_parameter.lambda_s3_bucket.*.value),
+  )
+  s3_key = coalesce(
+    var.lambda_s3_key,
+    join("", data.aws_ssm_parameter.lambda_s3_key.*.value),
+  )
+  runtime = "go1.x"
+  timeout = var.timeout
+
+  environment {
+    variables = {
+      RPO            = var.rpo
+      SNAPSHOT_LIMIT = var.snapshot_limit
+      VOLUME_DEVICES = join(",", var.device_names)
+      VOLUME_NAME    = var.volume_name
+    }
+  }
+}
+
+resource "aws_lambda_permission" "ebs_check" {
+  statement_id  = "AllowExecutionFromCloudWatch"
+  action        = "lambda:InvokeFunction"
+  function_name = aws_lambda_function.ebs_check.function_name
+  principal     = "events.amazonaws.com"
+  source_arn    = aws_cloudwatch_event_rule.ebs_check.arn
+}
+
+resource "aws_iam_role" "ebs_check" {
+  name_prefix = "ebs_check"
+
+  assume_role_policy = <<POLICY
+{
+    "Version": "2012-10-17",
+    "Statement": [
+        {
+            "Action": "sts:AssumeRole",
+            "Principal": {
+                "Service": "lambda.amazonaws.com"
+            },
+            "Effect": "Allow"
+        }
+    ]
+}
+POLICY
+
+}
+
+resource "aws_iam_role_policy" "ebs_check" {
+  name = "ebs_check"
+  role = aws_iam_role.ebs_check.name
+
+  policy = <<POLICY
+{
+    "Version": "2012-10-17",
+    "Statement": [
+        {
+            "Effect": "Allow",
+            "Action": [
+                "ec2:DescribeVolumes",
+                "ec2:DescribeSnapshots"
+            ],
+            "Resource": "*"
+        },
+        {
+            "Effect": "Allow",
+            "Action": [
+                "logs:CreateLogGroup",
+                "logs:CreateLogStream",
+                "logs:PutLogEvents"
+            ],
+            "Resource": "*"
+        }
+    ]
+}
+POLICY
+
+}
@@ -0,0 +1,11 @@
+output "check_function_name" {
+  value = aws_lambda_function.ebs_check.function_name
+}
+
+output "check_function_arn" {
+  value = aws_lambda_function.ebs_check.arn
+}
+
+output "check_alarm_arn" {
+  value = aws_cloudwatch_metric_alarm.ebs_check.arn
+}
@@ -95,13 +95,22 @@ func testCreateSnapshot(sess client.ConfigProvider, functionName string) func(*t
 		if err := json.Unmarshal(output.Payload, &resp); err != nil {
 			t.Fatal(err)
 		}
-		for i := range resp {
-			defer deleteSnapshot(sess, resp[i].SnapshotID)
+		for i := range resp.Results {
+			defer deleteSnapshot(sess, resp.Results[i].SnapshotID)
 		}
 
+		t.Run("Response version", func(t *testing.T) {
+			if resp.Version != handler.Version {
+				t.Errorf("Expected response version %d, actual %d", handler.Version, resp.Version)
+			}
+		})
+
 		t.Run("Single snapshot created", func(t *testing.T) {
-			if len(resp) != 1 {
-				t.Errorf("Expected 1 snapshot created, actual %d", len(resp))
+			if len(resp.Results) != 1 {
+				t.Errorf("Expected 1 snapshot created, actual %d", len(resp.Results))
+			}
+			if resp.Summary.Created != 1 {
+				t.Errorf("Expected summary of 1 snapshot created, actual %d", resp.Summary.Created)
 			}
 		})
 
@@ -110,7 +119,7 @@ func testCreateSnapshot(sess client.ConfigProvider, functionName string) func(*t
 		t.Run("Snapshot exists", func(t *testing.T) {
 			resp, err := ec2Client.DescribeSnapshots(
 				&ec2.DescribeSnapshotsInput{
-					SnapshotIds: aws.StringSlice([]string{resp[0].SnapshotID}),
+					SnapshotIds: aws.StringSlice([]string{resp.Results[0].SnapshotID}),
 				},
 			)
 			if err != nil {
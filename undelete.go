@@ -0,0 +1,75 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/recyclebin"
+	"github.com/segmentio/ebs-backup/internal/engine"
+)
+
+// undelete lists the recently deleted managed snapshots in the
+// Recycle Bin, and restores the snapshots given as arguments.
+func undelete(args []string) int {
+	fs := flag.NewFlagSet("undelete", flag.ExitOnError)
+	job := fs.String("job", "", "only list the snapshots of volumes backed up by this job")
+	since := fs.Duration("since", 7*24*time.Hour, "only list snapshots deleted within this duration, zero for all")
+	all := fs.Bool("all", false, "list all the snapshots in the Recycle Bin, not only managed ones")
+	fs.Parse(args)
+
+	sess := session.New(aws.NewConfig())
+
+	e := engine.New(engine.Config{
+		EC2:  ec2.New(sess),
+		Rbin: recyclebin.New(sess),
+		Job:  *job,
+	})
+
+	if ids := fs.Args(); len(ids) > 0 {
+		if err := e.Undelete(ids...); err != nil {
+			log.WithError(err).Fatal("undelete")
+		}
+
+		log.WithField("snapshots", ids).Info("restored")
+		return 0
+	}
+
+	rule, err := e.RecycleBin()
+	switch {
+	case err != nil:
+		log.WithError(err).Warn("recycle bin")
+	case rule.ID == "":
+		log.Warn("no recycle bin rule retains deleted snapshots, deletions are permanent")
+	default:
+		log.WithFields(log.Fields{
+			"rule":      rule.ID,
+			"retention": rule.Retention,
+		}).Info("recycle bin")
+	}
+
+	recycled, err := e.Recycled(*since, *all)
+	if err != nil {
+		log.WithError(err).Fatal("error")
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "SNAPSHOT\tVOLUME\tDELETED\tEXPIRES\tDESCRIPTION")
+
+	for _, r := range recycled {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n",
+			r.SnapshotID, r.VolumeID,
+			r.Deleted.UTC().Format(time.RFC3339),
+			r.Expires.UTC().Format(time.RFC3339),
+			r.Description)
+	}
+
+	tw.Flush()
+	return 0
+}